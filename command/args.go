@@ -0,0 +1,72 @@
+package command
+
+import "fmt"
+
+// PositionalArgs validates the positional arguments of a command.
+// Errors are reported as usage errors.
+type PositionalArgs func(args []string) error
+
+// ArbitraryArgs accepts any positional arguments.
+func ArbitraryArgs(args []string) error {
+	return nil
+}
+
+// NoArgs rejects any positional argument.
+func NoArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+	return nil
+}
+
+// ExactArgs requires exactly n positional arguments.
+func ExactArgs(n int) PositionalArgs {
+	return func(args []string) error {
+		if len(args) != n {
+			return fmt.Errorf("accepts %d arg(s), received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// MinimumNArgs requires at least n positional arguments.
+func MinimumNArgs(n int) PositionalArgs {
+	return func(args []string) error {
+		if len(args) < n {
+			return fmt.Errorf("requires at least %d arg(s), received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// MaximumNArgs accepts at most n positional arguments.
+func MaximumNArgs(n int) PositionalArgs {
+	return func(args []string) error {
+		if len(args) > n {
+			return fmt.Errorf("accepts at most %d arg(s), received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// RangeArgs requires between min and max positional arguments, inclusive.
+func RangeArgs(min, max int) PositionalArgs {
+	return func(args []string) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("accepts between %d and %d arg(s), received %d", min, max, len(args))
+		}
+		return nil
+	}
+}
+
+// MatchAll combines validators, returning the first error.
+func MatchAll(validators ...PositionalArgs) PositionalArgs {
+	return func(args []string) error {
+		for _, validate := range validators {
+			if err := validate(args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
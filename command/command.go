@@ -0,0 +1,379 @@
+// Package command dispatches subcommands built on flag.FlagSet.
+//
+// A program is a tree of Commands. Each command has its own FlagSet; flags
+// defined on PersistentFlags are also accepted by every descendant. Execute
+// walks the arguments to the requested command, parses flags level by level,
+// applies environment bindings (see flagutil.BindEnv), checks flag constraints
+// (see flagutil.MarkRequired) and calls Run.
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/soulteary/cli-kit/internal/suggest"
+)
+
+// Command is a node in a command tree.
+type Command struct {
+	// Name is the word that selects the command.
+	Name string
+	// Aliases are alternative words that select the command.
+	Aliases []string
+	// Short is the one-line summary shown in the parent's command list.
+	Short string
+	// Long is the description shown in the command's own help; defaults to Short.
+	Long string
+	// ArgsUsage describes positional arguments in the usage line, e.g. "<src> <dst>".
+	ArgsUsage string
+	// Args validates positional arguments before Run; nil accepts any.
+	Args PositionalArgs
+	// EnvPrefix binds every flag to an environment variable with this prefix
+	// (see flagutil.BindEnv). Commands without a prefix inherit their parent's.
+	EnvPrefix string
+	// Hidden omits the command from the parent's command list.
+	Hidden bool
+	// Run executes the command. Commands without Run only dispatch to subcommands.
+	Run func(ctx context.Context, cmd *Command, args []string) error
+
+	parent     *Command
+	children   []*Command
+	flags      *flag.FlagSet
+	persistent *flag.FlagSet
+	inherited  map[string]*inheritedValue
+	binding    *flagutil.EnvBinding
+	prepared   bool
+	out        io.Writer
+}
+
+// AddCommand adds subcommands to c.
+func (c *Command) AddCommand(cmds ...*Command) {
+	for _, cmd := range cmds {
+		cmd.parent = c
+		c.children = append(c.children, cmd)
+	}
+}
+
+// Commands returns the subcommands of c.
+func (c *Command) Commands() []*Command {
+	return append([]*Command(nil), c.children...)
+}
+
+// Parent returns the parent command, or nil for the root.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Flags returns the FlagSet holding the flags of c. While Run executes it
+// also holds the persistent flags of c and its ancestors, so it can be passed
+// to flagutil and configutil helpers.
+func (c *Command) Flags() *flag.FlagSet {
+	if c.flags == nil {
+		c.flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	return c.flags
+}
+
+// PersistentFlags returns the FlagSet for flags accepted by c and all of its descendants.
+func (c *Command) PersistentFlags() *flag.FlagSet {
+	if c.persistent == nil {
+		c.persistent = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	return c.persistent
+}
+
+// SetOutput sets where help and errors are written; the default is os.Stderr.
+// Subcommands use their parent's output unless they set their own.
+func (c *Command) SetOutput(w io.Writer) {
+	c.out = w
+}
+
+// Output returns where help and errors are written.
+func (c *Command) Output() io.Writer {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.out != nil {
+			return cmd.out
+		}
+	}
+	return os.Stderr
+}
+
+// Path returns the names from the root to c, e.g. "app remote add".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Find returns the direct subcommand selected by name or one of its aliases.
+func (c *Command) Find(name string) *Command {
+	for _, cmd := range c.children {
+		if cmd.Name == name || slices.Contains(cmd.Aliases, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// envPrefix returns the nearest EnvPrefix on the path to the root.
+func (c *Command) envPrefix() string {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.EnvPrefix != "" {
+			return cmd.EnvPrefix
+		}
+	}
+	return ""
+}
+
+// prepare merges persistent flags into Flags and binds the environment.
+// It runs once per command.
+func (c *Command) prepare() {
+	if c.prepared {
+		return
+	}
+	c.prepared = true
+	fs := c.Flags()
+	fs.Usage = func() {}
+	c.inherited = make(map[string]*inheritedValue)
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.persistent == nil {
+			continue
+		}
+		cmd.persistent.VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) != nil {
+				return
+			}
+			v := &inheritedValue{Value: f.Value}
+			c.inherited[f.Name] = v
+			fs.Var(v, f.Name, f.Usage)
+		})
+	}
+	if prefix := c.envPrefix(); prefix != "" {
+		c.binding = flagutil.BindEnv(fs, prefix)
+	}
+}
+
+// inheritedValue shares the value of a persistent flag with a descendant's
+// FlagSet. markOnly lets a flag given at an ancestor level be recorded as set
+// on the descendant without setting the value twice.
+type inheritedValue struct {
+	flag.Value
+	markOnly bool
+}
+
+// String is safe on the zero value flag.PrintDefaults creates to find out
+// whether a default is worth printing.
+func (v *inheritedValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func (v *inheritedValue) Set(s string) error {
+	if v.markOnly {
+		return nil
+	}
+	return v.Value.Set(s)
+}
+
+func (v *inheritedValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// Execute runs the command selected by args (usually os.Args[1:]).
+// Flags for a command come before its subcommand name, so in
+// "app --verbose serve --port 80" --verbose is parsed by app and --port by serve.
+// On -h or --help the command's help is written and flag.ErrHelp returned.
+// Usage problems are returned as *UsageError after writing the error and a
+// hint to the output.
+func (c *Command) Execute(ctx context.Context, args []string) error {
+	cmd := c
+	var setAbove []string
+	for {
+		cmd.prepare()
+		fs := cmd.Flags()
+		for _, name := range setAbove {
+			if v, ok := cmd.inherited[name]; ok && !flagutil.HasFlag(fs, name) {
+				v.markOnly = true
+				_ = fs.Set(name, "")
+				v.markOnly = false
+			}
+		}
+		fs.SetOutput(io.Discard)
+		if err := flagutil.Parse(fs, args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				_ = cmd.WriteHelp(cmd.Output())
+				return flag.ErrHelp
+			}
+			return cmd.usageError(err)
+		}
+		args = fs.Args()
+		fs.Visit(func(f *flag.Flag) {
+			if cmd.isPersistent(f.Name) && !slices.Contains(setAbove, f.Name) {
+				setAbove = append(setAbove, f.Name)
+			}
+		})
+
+		if len(cmd.children) == 0 {
+			break
+		}
+		if len(args) > 0 {
+			if next := cmd.Find(args[0]); next != nil {
+				cmd, args = next, args[1:]
+				continue
+			}
+		}
+		if cmd.Run != nil {
+			break
+		}
+		if len(args) == 0 {
+			_ = cmd.WriteHelp(cmd.Output())
+			return &UsageError{Err: fmt.Errorf("%s requires a command", cmd.Path())}
+		}
+		return cmd.usageError(fmt.Errorf("unknown command %q for %q%s", args[0], cmd.Path(),
+			suggest.Phrase(cmd.suggestCommands(args[0]), strconv.Quote)))
+	}
+
+	if cmd.binding != nil {
+		if err := cmd.binding.Apply(); err != nil {
+			return cmd.usageError(err)
+		}
+	}
+	if err := flagutil.CheckConstraints(cmd.Flags()); err != nil {
+		return cmd.usageError(err)
+	}
+	if cmd.Args != nil {
+		if err := cmd.Args(args); err != nil {
+			return cmd.usageError(err)
+		}
+	}
+	if cmd.Run == nil {
+		_ = cmd.WriteHelp(cmd.Output())
+		return nil
+	}
+	return cmd.Run(ctx, cmd, args)
+}
+
+// suggestCommands returns visible subcommands whose name or alias is close to name.
+func (c *Command) suggestCommands(name string) []string {
+	var candidates []string
+	owner := make(map[string]string)
+	for _, cmd := range c.children {
+		if cmd.Hidden {
+			continue
+		}
+		for _, n := range append([]string{cmd.Name}, cmd.Aliases...) {
+			candidates = append(candidates, n)
+			owner[n] = cmd.Name
+		}
+	}
+	var names []string
+	for _, match := range suggest.Suggest(name, candidates) {
+		if !slices.Contains(names, owner[match]) {
+			names = append(names, owner[match])
+		}
+	}
+	return names
+}
+
+// isPersistent reports whether name is a persistent flag of c or an ancestor.
+func (c *Command) isPersistent(name string) bool {
+	if _, ok := c.inherited[name]; ok {
+		return true
+	}
+	return c.persistent != nil && c.persistent.Lookup(name) != nil
+}
+
+// usageError reports err with a help hint and wraps it in a UsageError.
+func (c *Command) usageError(err error) error {
+	var usageErr *UsageError
+	if !errors.As(err, &usageErr) {
+		usageErr = &UsageError{Err: err}
+	}
+	fmt.Fprintf(c.Output(), "Error: %v\nRun '%s --help' for usage.\n", usageErr.Err, c.Path())
+	return usageErr
+}
+
+// WriteHelp writes the usage line, description, flags and subcommands of c to w.
+func (c *Command) WriteHelp(w io.Writer) error {
+	c.prepare()
+	u := flagutil.NewUsage(c.Flags())
+	u.Name = c.Path()
+	u.Synopsis = c.synopsis()
+	u.Description = c.Long
+	if u.Description == "" {
+		u.Description = c.Short
+	}
+	if err := u.WriteText(w); err != nil {
+		return err
+	}
+
+	var visible []*Command
+	width := 0
+	for _, cmd := range c.children {
+		if cmd.Hidden {
+			continue
+		}
+		visible = append(visible, cmd)
+		width = max(width, len(cmd.names()))
+	}
+	if len(visible) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("\nCommands:\n")
+	for _, cmd := range visible {
+		line := fmt.Sprintf("  %-*s    %s", width, cmd.names(), cmd.Short)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	fmt.Fprintf(&b, "\nRun '%s <command> --help' for more information on a command.\n", c.Path())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// names returns the name and aliases for the command list.
+func (c *Command) names() string {
+	return strings.Join(append([]string{c.Name}, c.Aliases...), ", ")
+}
+
+func (c *Command) synopsis() string {
+	parts := []string{c.Path()}
+	hasFlags := false
+	c.Flags().VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		parts = append(parts, "[flags]")
+	}
+	if len(c.children) > 0 {
+		if c.Run == nil {
+			parts = append(parts, "<command>")
+		} else {
+			parts = append(parts, "[command]")
+		}
+	}
+	if c.ArgsUsage != "" {
+		parts = append(parts, c.ArgsUsage)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Main runs Execute with os.Args[1:] and exits the process with ExitCode.
+// Errors other than usage errors are written to the output as "Error: ...".
+func (c *Command) Main(ctx context.Context) {
+	err := c.Execute(ctx, os.Args[1:])
+	var usageErr *UsageError
+	if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.As(err, &usageErr) {
+		fmt.Fprintf(c.Output(), "Error: %v\n", err)
+	}
+	os.Exit(ExitCode(err))
+}
@@ -0,0 +1,248 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/configutil"
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+type ctxKey struct{}
+
+// newTestTree builds "app [--verbose] serve|s [--port] <dir>" and "app remote add <name>".
+func newTestTree(out *bytes.Buffer, got *[]string) *Command {
+	root := &Command{Name: "app", Short: "Test application", EnvPrefix: "APP"}
+	root.SetOutput(out)
+	verbose := root.PersistentFlags().Bool("verbose", false, "Verbose output")
+	root.Flags().Bool("version", false, "Print version")
+
+	serve := &Command{
+		Name:      "serve",
+		Aliases:   []string{"s"},
+		Short:     "Start the server",
+		ArgsUsage: "<dir>",
+		Args:      ExactArgs(1),
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			port := configutil.ResolveInt(cmd.Flags(), "port", "APP_PORT", 8080, false)
+			*got = append(*got, "serve", args[0], strings.Repeat("v", boolInt(*verbose)),
+				strconv.Itoa(port), strings.Repeat("c", boolInt(ctx.Value(ctxKey{}) != nil)),
+				strings.Repeat("h", boolInt(flagutil.HasFlag(cmd.Flags(), "verbose"))))
+			return nil
+		},
+	}
+	serve.Flags().Int("port", 8080, "Listen port")
+
+	remote := &Command{Name: "remote", Short: "Manage remotes"}
+	add := &Command{
+		Name:  "add",
+		Short: "Add a remote",
+		Args:  MinimumNArgs(1),
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			*got = append(*got, "add", strings.Join(args, ","))
+			return nil
+		},
+	}
+	remote.AddCommand(add)
+	root.AddCommand(serve, remote, &Command{Name: "debug", Hidden: true})
+	return root
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestExecute_Dispatch(t *testing.T) {
+	t.Setenv("APP_PORT", "")
+	t.Setenv("APP_VERBOSE", "")
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"persistent flag before command", []string{"--verbose", "serve", "dir"}, "serve|dir|v|8080|c|h"},
+		{"persistent flag after command", []string{"serve", "-verbose", "--port", "9090", "dir"}, "serve|dir|v|9090|c|h"},
+		{"alias", []string{"s", "dir"}, "serve|dir||8080|c|"},
+		{"nested", []string{"remote", "add", "origin", "upstream"}, "add|origin,upstream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var got []string
+			root := newTestTree(&out, &got)
+			ctx := context.WithValue(context.Background(), ctxKey{}, true)
+			if err := root.Execute(ctx, tt.args); err != nil {
+				t.Fatalf("Execute(%q) failed: %v\n%s", tt.args, err, out.String())
+			}
+			if strings.Join(got, "|") != tt.want {
+				t.Errorf("Execute(%q) ran %q, want %q", tt.args, strings.Join(got, "|"), tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_Env(t *testing.T) {
+	t.Setenv("APP_PORT", "7070")
+	t.Setenv("APP_VERBOSE", "true")
+	var out bytes.Buffer
+	var got []string
+	root := newTestTree(&out, &got)
+	if err := root.Execute(context.Background(), []string{"serve", "dir"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "serve|dir|v|7070||h"; strings.Join(got, "|") != want {
+		t.Errorf("Execute() ran %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	got = nil
+	if err := newTestTree(&out, &got).Execute(context.Background(), []string{"serve", "--port", "1", "dir"}); err != nil {
+		t.Fatal(err)
+	}
+	if got[3] != "1" {
+		t.Errorf("CLI port = %q, want CLI to win over APP_PORT", got[3])
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	t.Setenv("APP_PORT", "")
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{"unknown command", []string{"bogus"}, ExitUsage, `Error: unknown command "bogus" for "app"`},
+		{"missing command", nil, ExitUsage, "Commands:"},
+		{"unknown flag", []string{"serve", "--nope", "dir"}, ExitUsage, "Run 'app serve --help' for usage."},
+		{"command suggestion", []string{"serev"}, ExitUsage, `unknown command "serev" for "app" (did you mean "serve"?)`},
+		{"flag suggestion", []string{"serve", "--prot", "1", "dir"}, ExitUsage, "flag provided but not defined: -prot (did you mean --port?)"},
+		{"bad args", []string{"serve"}, ExitUsage, "accepts 1 arg(s), received 0"},
+		{"root-only flag on subcommand", []string{"serve", "--version", "dir"}, ExitUsage, "flag provided but not defined: -version"},
+		{"help", []string{"serve", "-h"}, ExitOK, "Usage: app serve [flags] <dir>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var got []string
+			err := newTestTree(&out, &got).Execute(context.Background(), tt.args)
+			if code := ExitCode(err); code != tt.wantCode {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, code, tt.wantCode)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+			if len(got) != 0 {
+				t.Errorf("Run should not be called, got %q", got)
+			}
+		})
+	}
+}
+
+func TestWriteHelp(t *testing.T) {
+	var out bytes.Buffer
+	var got []string
+	root := newTestTree(&out, &got)
+	var help bytes.Buffer
+	if err := root.WriteHelp(&help); err != nil {
+		t.Fatal(err)
+	}
+	want := `Usage: app [flags] <command>
+
+Test application
+
+Flags:
+  --verbose    Verbose output [env: APP_VERBOSE]
+  --version    Print version [env: APP_VERSION]
+
+Commands:
+  serve, s    Start the server
+  remote      Manage remotes
+
+Run 'app <command> --help' for more information on a command.
+`
+	if help.String() != want {
+		t.Errorf("WriteHelp() =\n%s\nwant\n%s", help.String(), want)
+	}
+}
+
+func TestPrintDefaults_Inherited(t *testing.T) {
+	var out bytes.Buffer
+	var got []string
+	root := newTestTree(&out, &got)
+	serve := root.Find("serve")
+	serve.prepare()
+	var defaults bytes.Buffer
+	fs := serve.Flags()
+	fs.SetOutput(&defaults)
+	fs.PrintDefaults()
+	if s := defaults.String(); !strings.Contains(s, "-verbose") || strings.Contains(s, "panic") {
+		t.Errorf("PrintDefaults() =\n%s", s)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	root := &Command{Name: "app", Run: func(context.Context, *Command, []string) error {
+		return WithExitCode(errors.New("not found"), 4)
+	}}
+	err := root.Execute(context.Background(), nil)
+	if ExitCode(err) != 4 || err.Error() != "not found" {
+		t.Errorf("Execute() = %v (code %d), want exit code 4", err, ExitCode(err))
+	}
+	if ExitCode(errors.New("boom")) != ExitError || ExitCode(nil) != ExitOK || ExitCode(flag.ErrHelp) != ExitOK {
+		t.Error("ExitCode() default mapping is wrong")
+	}
+	if WithExitCode(nil, 3) != nil {
+		t.Error("WithExitCode(nil) should return nil")
+	}
+}
+
+func TestPositionalArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      PositionalArgs
+		args    []string
+		wantErr bool
+	}{
+		{"arbitrary", ArbitraryArgs, []string{"a", "b"}, false},
+		{"no args ok", NoArgs, nil, false},
+		{"no args fail", NoArgs, []string{"a"}, true},
+		{"exact ok", ExactArgs(2), []string{"a", "b"}, false},
+		{"exact fail", ExactArgs(2), []string{"a"}, true},
+		{"min fail", MinimumNArgs(2), []string{"a"}, true},
+		{"max ok", MaximumNArgs(1), []string{"a"}, false},
+		{"max fail", MaximumNArgs(1), []string{"a", "b"}, true},
+		{"range ok", RangeArgs(1, 2), []string{"a", "b"}, false},
+		{"range fail", RangeArgs(1, 2), nil, true},
+		{"all fail", MatchAll(MinimumNArgs(1), MaximumNArgs(1)), []string{"a", "b"}, true},
+		{"all ok", MatchAll(MinimumNArgs(1), MaximumNArgs(1)), []string{"a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validator(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecute_Constraints(t *testing.T) {
+	var out bytes.Buffer
+	root := &Command{Name: "app", Run: func(context.Context, *Command, []string) error { return nil }}
+	root.SetOutput(&out)
+	root.Flags().String("token", "", "API token")
+	root.Flags().String("token-file", "", "API token file")
+	flagutil.MarkMutuallyExclusive(root.Flags(), "token", "token-file")
+
+	err := root.Execute(context.Background(), []string{"--token", "t", "--token-file", "f"})
+	if ExitCode(err) != ExitUsage || !strings.Contains(out.String(), "flags --token and --token-file cannot be used together") {
+		t.Errorf("Execute() = %v, output %q, want a constraint usage error", err, out.String())
+	}
+}
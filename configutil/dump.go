@@ -0,0 +1,233 @@
+package configutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+// ErrUnknownDumpFormat is returned by Dump for a format it does not know.
+var ErrUnknownDumpFormat = fmt.Errorf("unknown dump format")
+
+// DumpFormat selects the output of Dump.
+type DumpFormat string
+
+const (
+	// DumpText is an aligned SETTING/VALUE table.
+	DumpText DumpFormat = "text"
+	// DumpJSON is a JSON object keyed like the config file Load reads.
+	DumpJSON DumpFormat = "json"
+	// DumpEnv is an env file of KEY=value lines.
+	DumpEnv DumpFormat = "env"
+	// DumpFlags is one --name=value argument per line.
+	DumpFlags DumpFormat = "flags"
+)
+
+// DumpFormats lists the formats Dump supports, e.g. for a flagutil.Enum flag.
+func DumpFormats() []string {
+	return []string{string(DumpText), string(DumpJSON), string(DumpEnv), string(DumpFlags)}
+}
+
+// DumpOption configures Dump.
+type DumpOption func(*dumpOptions)
+
+type dumpOptions struct {
+	recorder  *Recorder
+	envPrefix string
+}
+
+// WithProvenance annotates each setting with where Load resolved it from, as
+// recorded by rec, the LoadOptions.Recorder cfg was loaded with: extra SOURCE
+// and KEY columns in the text table and a "# env APP_PORT" comment line above
+// each entry in the env and flags formats. JSON has no comments and carries no provenance.
+func WithProvenance(rec *Recorder) DumpOption {
+	return func(o *dumpOptions) { o.recorder = rec }
+}
+
+// WithEnvPrefix sets the env prefix; pass the LoadOptions.EnvPrefix cfg was
+// loaded with so env variables and env-only settings get the names Load used.
+func WithEnvPrefix(prefix string) DumpOption {
+	return func(o *dumpOptions) { o.envPrefix = prefix }
+}
+
+// Dump writes the effective configuration held in cfg, a struct or pointer
+// to a struct tagged for Load, in the given format. The env and flags output
+// loads back with Load, the flags output as a response file; fields without an
+// env variable or flag are left out of those formats. Secret fields are shown
+// as flagutil.RedactedValue in the text table, written as a commented-out line
+// without their value in the env and flags formats, and left out of JSON, so
+// a dump never feeds a placeholder back in as a value. Values needing it are
+// single-quoted as in a POSIX shell, so a shell can source the env output.
+//
+//	var rec configutil.Recorder
+//	err := configutil.Load(&cfg, fs, &configutil.LoadOptions{EnvPrefix: "APP", Recorder: &rec})
+//	if format := printConfig.String(); format != "" {
+//		return configutil.Dump(&cfg, configutil.DumpFormat(format), os.Stdout,
+//			configutil.WithEnvPrefix("APP"), configutil.WithProvenance(&rec))
+//	}
+func Dump(cfg any, format DumpFormat, w io.Writer, opts ...DumpOption) error {
+	var o dumpOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("configutil: Dump needs a struct or a pointer to one, got %T", cfg)
+	}
+	var fields []*loadField
+	if err := collectFields(&fields, rv, "", o.envPrefix); err != nil {
+		return err
+	}
+
+	switch format {
+	case DumpText:
+		return dumpText(w, fields, o)
+	case DumpJSON:
+		return dumpJSON(w, fields)
+	case DumpEnv, DumpFlags:
+		var b strings.Builder
+		for _, f := range fields {
+			key := f.env
+			if format == DumpFlags {
+				key = f.flag
+			}
+			if key == "" {
+				continue
+			}
+			if format == DumpFlags {
+				key = "--" + key
+			}
+			if f.secret {
+				b.WriteString("# " + key + "=  (secret, not written)\n")
+				continue
+			}
+			// Comments stay on their own line: some env file parsers read
+			// a trailing comment as part of the value.
+			if note := o.provenance(f); note != "" {
+				b.WriteString("# " + note + "\n")
+			}
+			b.WriteString(key + "=" + quoteValue(f.dumpValue()) + "\n")
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownDumpFormat, format)
+}
+
+func dumpText(w io.Writer, fields []*loadField, o dumpOptions) error {
+	header := []string{"SETTING", "VALUE"}
+	if o.recorder != nil {
+		header = append(header, "SOURCE", "KEY")
+	}
+	rows := [][]string{header}
+	for _, f := range fields {
+		row := []string{f.name(), f.dumpValue()}
+		if o.recorder != nil {
+			source, key := "", ""
+			if s, ok := o.recorder.lookup(f.name()); ok {
+				source, key = s.source.String(), s.key
+			}
+			row = append(row, source, key)
+		}
+		rows = append(rows, row)
+	}
+	return writeTable(w, rows)
+}
+
+func dumpJSON(w io.Writer, fields []*loadField) error {
+	doc := make(map[string]any)
+	for _, f := range fields {
+		if f.secret {
+			continue
+		}
+		value := jsonValue(f.value)
+		parts := strings.Split(f.fileKey(), ".")
+		m := doc
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := m[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				m[part] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = value
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// jsonValue keeps numbers, booleans and lists typed; durations are strings.
+func jsonValue(v reflect.Value) any {
+	switch {
+	case v.Type() == durationType:
+		return formatField(v)
+	case v.Kind() == reflect.Slice:
+		items := v.Convert(reflect.TypeFor[[]string]()).Interface().([]string)
+		if items == nil {
+			items = []string{}
+		}
+		return items
+	}
+	return v.Interface()
+}
+
+// name is the setting name Load records the field under.
+func (f *loadField) name() string {
+	switch {
+	case f.flag != "":
+		return f.flag
+	case f.env != "":
+		return f.env
+	}
+	return f.configKey
+}
+
+func (f *loadField) dumpValue() string {
+	if f.secret {
+		return flagutil.RedactedValue
+	}
+	return formatField(f.value)
+}
+
+// provenance describes the recorded source of f, e.g. "env APP_PORT".
+func (o dumpOptions) provenance(f *loadField) string {
+	if o.recorder == nil {
+		return ""
+	}
+	s, ok := o.recorder.lookup(f.name())
+	switch {
+	case !ok:
+		return ""
+	case s.source == flagutil.SourceCLI:
+		return "cli --" + s.key
+	case s.key == "":
+		return s.source.String()
+	}
+	return s.source.String() + " " + s.key
+}
+
+// shellSafe holds the characters a value may use unquoted.
+const shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+// quoteValue single-quotes values a shell or response file would not read
+// back as written; nothing is expanded inside single quotes. A quote in the
+// value closes the quoting, is written escaped, and reopens it.
+func quoteValue(s string) string {
+	unsafe := func(r rune) bool { return !strings.ContainsRune(shellSafe, r) }
+	if !strings.ContainsFunc(s, unsafe) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,226 @@
+package configutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+type dumpTestConfig struct {
+	Port    int           `flag:"port" env:"PORT" default:"8080"`
+	Debug   bool          `flag:"debug" env:"DEBUG"`
+	Timeout time.Duration `flag:"timeout" env:"TIMEOUT" default:"30s"`
+	Tags    []string      `flag:"tags" env:"TAGS"`
+	Greet   string        `flag:"greet" env:"GREET" default:"hello world # $HOME"`
+	Region  string        `env:"REGION" default:"eu"`
+	Server  struct {
+		Host string `flag:"host" default:"localhost"`
+	} `flag:"server" env:"SERVER"`
+}
+
+func loadDumpConfig(t *testing.T, args []string) dumpTestConfig {
+	t.Helper()
+	var cfg dumpTestConfig
+	if err := Load(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: args, EnvPrefix: "DUMP"}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	return cfg
+}
+
+func unsetDumpEnv(t *testing.T) {
+	for _, key := range []string{"PORT", "DEBUG", "TIMEOUT", "TAGS", "GREET", "REGION", "SERVER_HOST"} {
+		unsetEnv(t, "DUMP_"+key)
+	}
+}
+
+func TestDump(t *testing.T) {
+	unsetDumpEnv(t)
+	t.Setenv("DUMP_PORT", "9090")
+
+	type config struct {
+		Port  int      `flag:"port" env:"PORT" default:"8080"`
+		Tags  []string `flag:"tags"`
+		Token string   `flag:"token" secret:"true" default:"s3cr3t"`
+		Mode  string   `env:"MODE" default:"fast"`
+	}
+	var cfg config
+	var rec Recorder
+	if err := Load(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: []string{"-tags", "a,b"}, EnvPrefix: "DUMP", Recorder: &rec}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format DumpFormat
+		opts   []DumpOption
+		want   string
+	}{
+		{DumpText, nil, `SETTING    VALUE
+port       9090
+tags       a,b
+token      [REDACTED]
+DUMP_MODE  fast
+`},
+		{DumpText, []DumpOption{WithProvenance(&rec)}, `SETTING    VALUE       SOURCE   KEY
+port       9090        env      DUMP_PORT
+tags       a,b         cli      tags
+token      [REDACTED]  default
+DUMP_MODE  fast        default
+`},
+		{DumpEnv, []DumpOption{WithProvenance(&rec)}, `# env DUMP_PORT
+DUMP_PORT=9090
+# cli --tags
+DUMP_TAGS=a,b
+# DUMP_TOKEN=  (secret, not written)
+# default
+DUMP_MODE=fast
+`},
+		{DumpFlags, []DumpOption{WithProvenance(&rec)}, `# env DUMP_PORT
+--port=9090
+# cli --tags
+--tags=a,b
+# --token=  (secret, not written)
+`},
+		{DumpJSON, nil, `{
+  "DUMP_MODE": "fast",
+  "port": 9090,
+  "tags": [
+    "a",
+    "b"
+  ]
+}
+`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Dump(&cfg, tt.format, &out, append(tt.opts, WithEnvPrefix("DUMP"))...); err != nil {
+			t.Fatalf("Dump(%s) failed: %v", tt.format, err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("Dump(%s) =\n%s\nwant:\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	for _, format := range []DumpFormat{DumpEnv, DumpFlags, DumpJSON} {
+		var out bytes.Buffer
+		if err := Dump(&cfg, format, &out); err != nil || strings.Contains(out.String(), "s3cr3t") || strings.Contains(out.String(), flagutil.RedactedValue) {
+			t.Errorf("Dump(%s) = %q, %v, want the secret left out", format, out.String(), err)
+		}
+	}
+	if err := Dump(cfg, "yaml", &bytes.Buffer{}); !errors.Is(err, ErrUnknownDumpFormat) {
+		t.Errorf("Dump(yaml) error = %v, want ErrUnknownDumpFormat", err)
+	}
+	if err := Dump(42, DumpText, &bytes.Buffer{}); err == nil {
+		t.Error("Dump() should reject a non-struct")
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := map[string]string{
+		"":           "",
+		"9090":       "9090",
+		"a,b":        "a,b",
+		"http://x/y": "http://x/y",
+		"a b":        "'a b'",
+		"it's":       `'it'\''s'`,
+		"$HOME `id`": "'$HOME `id`'",
+		"tab\there":  "'tab\there'",
+	}
+	for in, want := range tests {
+		if got := quoteValue(in); got != want {
+			t.Errorf("quoteValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// roundTripGreet needs quoting for every reason quoteValue knows of.
+const roundTripGreet = "say \"hi\"\tto $HOME's `id` # not a comment\nbye \\ now"
+
+func TestDump_RoundTrip(t *testing.T) {
+	unsetDumpEnv(t)
+	var want dumpTestConfig
+	var rec Recorder
+	args := []string{"-port", "9443", "-debug", "-timeout", "1m30s", "-tags", "a,b", "-greet", roundTripGreet, "-server.host", "example.com"}
+	if err := Load(&want, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: args, EnvPrefix: "DUMP", Recorder: &rec}); err != nil {
+		t.Fatal(err)
+	}
+	want.Region = "us"
+	// Provenance comments must not leak into the values read back.
+	provenance := WithProvenance(&rec)
+
+	t.Run("env", func(t *testing.T) {
+		sh, err := exec.LookPath("sh")
+		if err != nil {
+			t.Skip("no POSIX shell")
+		}
+		var out bytes.Buffer
+		if err := Dump(want, DumpEnv, &out, WithEnvPrefix("DUMP"), provenance); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "app.env")
+		if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// Source the file with a real shell and print each variable it set.
+		keys := []string{"DUMP_PORT", "DUMP_DEBUG", "DUMP_TIMEOUT", "DUMP_TAGS", "DUMP_GREET", "DUMP_REGION", "DUMP_SERVER_HOST"}
+		script := `. "$1" && printf '%s\0'`
+		for _, key := range keys {
+			script += ` "$` + key + `"`
+		}
+		cmd := exec.Command(sh, "-c", script, "sh", path)
+		cmd.Env = []string{}
+		values, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("sourcing the env dump failed: %v\n%s", err, out.String())
+		}
+		for i, value := range strings.Split(strings.TrimSuffix(string(values), "\x00"), "\x00") {
+			t.Setenv(keys[i], value)
+		}
+		if got := loadDumpConfig(t, []string{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("env round trip = %+v, want %+v\n%s", got, want, out.String())
+		}
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		unsetDumpEnv(t)
+		var out bytes.Buffer
+		if err := Dump(want, DumpFlags, &out, provenance); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "app.args")
+		if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		args, err := flagutil.ExpandResponseFiles([]string{"@" + path}, nil)
+		if err != nil {
+			t.Fatalf("ExpandResponseFiles() failed: %v\n%s", err, out.String())
+		}
+		t.Setenv("DUMP_REGION", "us") // env-only fields have no flag
+		if got := loadDumpConfig(t, args); !reflect.DeepEqual(got, want) {
+			t.Errorf("flags round trip = %+v, want %+v\n%s", got, want, out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := Dump(want, DumpJSON, &out); err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+			t.Fatalf("Dump(json) is not valid JSON: %v\n%s", err, out.String())
+		}
+		if doc["timeout"] != "1m30s" || doc["port"] != float64(9443) || doc["server"].(map[string]any)["host"] != "example.com" {
+			t.Errorf("Dump(json) = %s", out.String())
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package configutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+// Resolved is a value returned by ResolveDetailed together with its provenance.
+type Resolved[T any] struct {
+	Value T
+	// Source is where the value came from.
+	Source flagutil.Source
+	// Key is the flag name or environment variable that won; empty for defaults.
+	Key string
+	// Raw is the string the value was parsed from.
+	Raw string
+	// Rejected lists the candidates tried before, in order, with the reason
+	// each was rejected.
+	Rejected []*ValueError
+}
+
+// setting is one line of the Explain report.
+type setting struct {
+	name     string
+	value    string
+	source   flagutil.Source
+	key      string
+	secret   bool
+	rejected []*ValueError
+}
+
+// Recorder collects the settings resolved through it for Explain. The zero
+// value is ready to use. Nothing is recorded unless a Recorder is passed with
+// WithRecorder or LoadOptions.Recorder, or installed with SetRecorder.
+type Recorder struct {
+	mu     sync.Mutex
+	list   []setting
+	index  map[string]int
+	secret map[string]bool
+}
+
+var defaultRecorder atomic.Pointer[Recorder]
+
+// SetRecorder installs r as the Recorder of every resolution not given one
+// with WithRecorder, including those of the Resolve* wrappers, and returns
+// the previous one. nil, the default, turns recording off.
+func SetRecorder(r *Recorder) *Recorder {
+	return defaultRecorder.Swap(r)
+}
+
+// MarkSecret redacts the named settings in Explain, for values resolved by
+// wrappers that cannot be passed WithSecret:
+//
+//	rec.MarkSecret("db-password")
+//	password := configutil.ResolveString(fs, "db-password", "DB_PASSWORD", "", false)
+func (r *Recorder) MarkSecret(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.secret == nil {
+		r.secret = make(map[string]bool)
+	}
+	for _, name := range names {
+		r.secret[name] = true
+	}
+}
+
+// record stores a resolution, replacing an earlier one of the same name.
+func (r *Recorder) record(s setting) {
+	if s.name == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.secret = s.secret || r.secret[s.name]
+	if r.index == nil {
+		r.index = make(map[string]int)
+	}
+	if i, ok := r.index[s.name]; ok {
+		r.list[i] = s
+		return
+	}
+	r.index[s.name] = len(r.list)
+	r.list = append(r.list, s)
+}
+
+// lookup returns the setting recorded under name.
+func (r *Recorder) lookup(name string) (setting, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.index[name]
+	if !ok {
+		return setting{}, false
+	}
+	return r.list[i], true
+}
+
+// settingName is the WithName option or the first source key.
+func settingName(o resolveOptions, sources []Source) string {
+	if o.name != "" {
+		return o.name
+	}
+	for _, src := range sources {
+		if src.Key != "" {
+			return src.Key
+		}
+	}
+	return ""
+}
+
+// Explain writes a table of every setting recorded by r, in the order they
+// were first resolved: its value, the source that won, the flag or
+// environment variable it was read from and the candidates that were
+// rejected. Values of secret settings, and their rejected candidates, are
+// shown as flagutil.RedactedValue.
+//
+//	SETTING  VALUE  SOURCE   KEY   REJECTED
+//	port     9090   env      PORT  invalid value "abc" for flag --port: ...
+//	debug    false  default
+func (r *Recorder) Explain(w io.Writer) error {
+	r.mu.Lock()
+	list := append([]setting(nil), r.list...)
+	r.mu.Unlock()
+
+	rows := [][]string{{"SETTING", "VALUE", "SOURCE", "KEY", "REJECTED"}}
+	for _, s := range list {
+		value := s.value
+		rejected := make([]string, len(s.rejected))
+		for i, r := range s.rejected {
+			rejected[i] = r.Error()
+			if s.secret {
+				rejected[i] = r.redacted()
+			}
+		}
+		if s.secret {
+			value = flagutil.RedactedValue
+		}
+		rows = append(rows, []string{s.name, value, s.source.String(), s.key, strings.Join(rejected, "; ")})
+	}
+	return writeTable(w, rows)
+}
+
+// writeTable writes rows as aligned columns.
+func writeTable(w io.Writer, rows [][]string) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// Padding of empty trailing cells is not part of the table.
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, strings.TrimRight(line, " \n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset forgets every setting recorded by r; settings marked secret stay so.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.list = nil
+	r.index = nil
+}
+
+// redacted describes e without the raw value or the parse error, which often
+// quotes it.
+func (e *ValueError) redacted() string {
+	redacted := *e
+	redacted.Raw = flagutil.RedactedValue
+	redacted.Err = errRedacted
+	return redacted.Error()
+}
+
+var errRedacted = fmt.Errorf("rejected")
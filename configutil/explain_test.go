@@ -0,0 +1,96 @@
+package configutil
+
+import (
+	"bytes"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+func TestResolveDetailed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "port")
+	if err := fs.Parse([]string{"--port", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PORT", "9090")
+
+	r, err := ResolveDetailed([]Source{FromFlag(fs, "port"), FromEnv("TEST_PORT", true), FromDefault("8080")}, strconv.Atoi)
+	if err != nil {
+		t.Fatalf("ResolveDetailed() failed: %v", err)
+	}
+	if r.Value != 9090 || r.Source != flagutil.SourceEnv || r.Key != "TEST_PORT" || r.Raw != "9090" {
+		t.Errorf("ResolveDetailed() = %+v, want 9090 from env TEST_PORT", r)
+	}
+	if len(r.Rejected) != 1 || r.Rejected[0].Kind != flagutil.SourceCLI || r.Rejected[0].Raw != "abc" || r.Rejected[0].Err == nil {
+		t.Errorf("Rejected = %v, want the CLI value with its error", r.Rejected)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	var rec Recorder
+	previous := SetRecorder(&rec)
+	defer SetRecorder(previous)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "port")
+	fs.Bool("debug", false, "debug")
+	token := flagutil.NewSecretValue("token")
+	fs.Var(token, "token", "API token")
+	if err := fs.Parse([]string{"--port", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PORT", "9090")
+	t.Setenv("TEST_TOKEN", "s3cr3t")
+	t.Setenv("TEST_PASSWORD", "hunter2")
+	t.Setenv("TEST_TAGS", "a, b")
+	t.Setenv("TEST_DB_PASSWORD", "letmein")
+	rec.MarkSecret("db-password")
+
+	ResolveInt(fs, "port", "TEST_PORT", 8080, false)
+	ResolveBool(fs, "debug", "TEST_DEBUG", false)
+	ResolveString(fs, "token", "TEST_TOKEN", "", true)
+	_, _ = Resolve([]Source{FromEnv("TEST_PASSWORD", true), FromDefault("1")}, strconv.Atoi, WithName("password"), WithSecret())
+	ResolveStringSlice(fs, "tags", "TEST_TAGS", nil, ",")
+	ResolveString(fs, "db-password", "TEST_DB_PASSWORD", "", false)
+	ResolveInt(fs, "port", "TEST_PORT", 8080, false) // resolving again replaces, not duplicates
+
+	var out bytes.Buffer
+	if err := rec.Explain(&out); err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+	// ResolveInt uses the default for an unparseable CLI value.
+	want := `SETTING      VALUE       SOURCE   KEY               REJECTED
+port         8080        default                    invalid value "abc" for flag --port: strconv.Atoi: parsing "abc": invalid syntax
+debug        false       default
+token        [REDACTED]  env      TEST_TOKEN
+password     [REDACTED]  default                    invalid value "[REDACTED]" for env TEST_PASSWORD: rejected
+tags         [a b]       env      TEST_TAGS
+db-password  [REDACTED]  env      TEST_DB_PASSWORD
+`
+	if got := out.String(); got != want {
+		t.Errorf("Explain() =\n%s\nwant:\n%s", got, want)
+	}
+	for _, secret := range []string{"s3cr3t", "hunter2", "letmein"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("Explain() leaked %q", secret)
+		}
+	}
+
+	rec.Reset()
+	other := &Recorder{}
+	_, _ = Resolve([]Source{FromDefault("1")}, strconv.Atoi, WithName("workers"), WithRecorder(other))
+	SetRecorder(nil)
+	ResolveString(fs, "db-password", "TEST_DB_PASSWORD", "", false)
+	out.Reset()
+	if err := rec.Explain(&out); err != nil || out.String() != "SETTING  VALUE  SOURCE  KEY  REJECTED\n" {
+		t.Errorf("Explain() after Reset and without a recorder =\n%s", out.String())
+	}
+	out.Reset()
+	if err := other.Explain(&out); err != nil || !strings.Contains(out.String(), "workers") {
+		t.Errorf("WithRecorder() did not record:\n%s", out.String())
+	}
+}
@@ -0,0 +1,371 @@
+package configutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/soulteary/cli-kit/validator"
+)
+
+// ErrUnsupportedConfigFormat is returned by LoadConfigFile for a file
+// extension with no registered format.
+var ErrUnsupportedConfigFormat = fmt.Errorf("unsupported config file format")
+
+// ConfigDecoder decodes a config file into nested maps. Objects become
+// map[string]any, arrays []any, and leaves strings, numbers or booleans.
+// It may return a *ConfigError with Line and Column set to locate a syntax
+// error; LoadConfigFile fills in the path.
+type ConfigDecoder func(data []byte) (map[string]any, error)
+
+// ConfigError reports a problem in a config file, with its position when known.
+type ConfigError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// configValue is one leaf of a config file.
+type configValue struct {
+	raw string
+	// line and column locate the value; zero when the format does not say.
+	line, column int
+}
+
+type configDecoder func(data []byte) (map[string]configValue, error)
+
+var configFormats = struct {
+	sync.Mutex
+	decoders map[string]configDecoder
+}{decoders: map[string]configDecoder{"json": decodeJSONConfig}}
+
+// RegisterConfigFormat makes LoadConfigFile read files with extension ext
+// (without the dot, case-insensitive) using decode, e.g. to plug in TOML,
+// YAML or INI:
+//
+//	configutil.RegisterConfigFormat("yaml", func(data []byte) (map[string]any, error) {
+//		var m map[string]any
+//		return m, yaml.Unmarshal(data, &m)
+//	})
+//
+// JSON is built in; registering "json" replaces it.
+func RegisterConfigFormat(ext string, decode ConfigDecoder) {
+	configFormats.Lock()
+	defer configFormats.Unlock()
+	configFormats.decoders[strings.ToLower(ext)] = func(data []byte) (map[string]configValue, error) {
+		doc, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]configValue)
+		return values, flattenConfig(values, "", doc)
+	}
+}
+
+// ConfigFile is a loaded config file whose values are looked up by dotted
+// key: {"server": {"port": 8080}} holds "server.port".
+type ConfigFile struct {
+	path   string
+	values map[string]configValue
+}
+
+// LoadConfigFile reads a config file in the format registered for its
+// extension. The path is checked with validator.ValidatePath and
+// validator.ValidateFileReadable; syntax errors are *ConfigError values
+// carrying the line and column.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	safePath, err := validator.ValidatePath(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := validator.ValidateFileReadable(safePath); err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	configFormats.Lock()
+	decode, ok := configFormats.decoders[ext]
+	configFormats.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedConfigFormat, path)
+	}
+	data, err := os.ReadFile(safePath)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decode(data)
+	if err != nil {
+		var ce *ConfigError
+		if errors.As(err, &ce) {
+			located := *ce
+			located.Path = path
+			return nil, &located
+		}
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	return &ConfigFile{path: path, values: values}, nil
+}
+
+// Path returns the path the file was loaded from.
+func (c *ConfigFile) Path() string { return c.path }
+
+// Lookup returns the value of a dotted key as text. Arrays are joined with
+// commas; null values count as unset.
+func (c *ConfigFile) Lookup(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	v, ok := c.values[key]
+	return v.raw, ok
+}
+
+// Keys returns the dotted keys of every value in the file, sorted.
+func (c *ConfigFile) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// where locates a key for error messages, e.g. "app.json:3:12".
+func (c *ConfigFile) where(key string) string {
+	v := c.values[key]
+	if v.line == 0 {
+		return c.path
+	}
+	return fmt.Sprintf("%s:%d:%d", c.path, v.line, v.column)
+}
+
+// FromFile is the value of a dotted key in cf. A nil cf is never set.
+func FromFile(cf *ConfigFile, key string) Source {
+	src := Source{Kind: flagutil.SourceFile, Key: key, lookup: func() (string, bool) {
+		return cf.Lookup(key)
+	}}
+	if cf != nil {
+		src.where = cf.where(key)
+	}
+	return src
+}
+
+// ChainOption adds a layer to the chain of a Resolve* function.
+type ChainOption func(*chainLayers)
+
+type chainLayers struct {
+	file    *ConfigFile
+	fileKey string
+}
+
+// WithConfigFile reads cf under the dotted key (e.g. "server.port") between
+// the environment and the default, so a Resolve* function resolves as
+// CLI > ENV > config file > default. A nil cf adds nothing.
+//
+//	port := configutil.ResolveInt(fs, "port", "APP_PORT", 8080, false,
+//		configutil.WithConfigFile(cf, "server.port"))
+func WithConfigFile(cf *ConfigFile, key string) ChainOption {
+	return func(l *chainLayers) { l.file, l.fileKey = cf, key }
+}
+
+// chain returns the sources of the Resolve* functions: cli, env, the config
+// file layer when one is given, and the default.
+func chain(cli, env Source, defaultValue string, layers ...ChainOption) []Source {
+	var l chainLayers
+	for _, layer := range layers {
+		layer(&l)
+	}
+	if l.file == nil {
+		return []Source{cli, env, FromDefault(defaultValue)}
+	}
+	return []Source{cli, env, FromFile(l.file, l.fileKey), FromDefault(defaultValue)}
+}
+
+// flattenConfig stores the leaves of doc in values under dotted keys.
+func flattenConfig(values map[string]configValue, prefix string, doc map[string]any) error {
+	for key, v := range doc {
+		key = prefix + key
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenConfig(values, key+".", v); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, err := configScalar(item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				items[i] = s
+			}
+			values[key] = configValue{raw: strings.Join(items, ",")}
+		case nil:
+		default:
+			s, err := configScalar(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			values[key] = configValue{raw: s}
+		}
+	}
+	return nil
+}
+
+func configScalar(v any) (string, error) {
+	switch v.(type) {
+	case map[string]any, []any, nil:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+	return fmt.Sprint(v), nil
+}
+
+// decodeJSONConfig reads a JSON object, recording where each value starts.
+func decodeJSONConfig(data []byte) (map[string]configValue, error) {
+	d := &jsonConfigDecoder{data: data, dec: json.NewDecoder(bytes.NewReader(data)), values: make(map[string]configValue)}
+	d.dec.UseNumber()
+	tok, err := d.token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, d.errorAt(0, fmt.Errorf("config file must hold a JSON object"))
+	}
+	if err := d.object(""); err != nil {
+		return nil, err
+	}
+	if _, err := d.dec.Token(); err != io.EOF {
+		return nil, d.errorAt(d.dec.InputOffset(), fmt.Errorf("unexpected data after the top-level object"))
+	}
+	return d.values, nil
+}
+
+type jsonConfigDecoder struct {
+	data   []byte
+	dec    *json.Decoder
+	values map[string]configValue
+}
+
+// object reads the members of an object whose "{" has been read.
+func (d *jsonConfigDecoder) object(prefix string) error {
+	for d.dec.More() {
+		tok, err := d.token()
+		if err != nil {
+			return err
+		}
+		key := prefix + tok.(string)
+		start := d.valueStart()
+		tok, err = d.token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			if err := d.object(key + "."); err != nil {
+				return err
+			}
+		case json.Delim('['):
+			var items []string
+			for d.dec.More() {
+				itemStart := d.valueStart()
+				item, err := d.token()
+				if err != nil {
+					return err
+				}
+				s, ok := jsonScalar(item)
+				if !ok {
+					return d.errorAt(itemStart, fmt.Errorf("%s: arrays may only hold strings, numbers and booleans", key))
+				}
+				items = append(items, s)
+			}
+			if _, err := d.token(); err != nil {
+				return err
+			}
+			d.set(key, strings.Join(items, ","), start)
+		case nil:
+		default:
+			s, _ := jsonScalar(tok)
+			d.set(key, s, start)
+		}
+	}
+	_, err := d.token() // "}"
+	return err
+}
+
+func (d *jsonConfigDecoder) set(key, raw string, offset int64) {
+	line, column := position(d.data, offset)
+	d.values[key] = configValue{raw: raw, line: line, column: column}
+}
+
+// valueStart returns the offset of the next value, past whitespace and the
+// ":" or "," before it.
+func (d *jsonConfigDecoder) valueStart() int64 {
+	offset := d.dec.InputOffset()
+	for offset < int64(len(d.data)) && strings.IndexByte(" \t\r\n:,", d.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (d *jsonConfigDecoder) token() (json.Token, error) {
+	tok, err := d.dec.Token()
+	if err == nil {
+		return tok, nil
+	}
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		// Offset counts the offending byte.
+		return nil, d.errorAt(max(syntax.Offset-1, 0), err)
+	}
+	if err == io.EOF {
+		return nil, d.errorAt(int64(len(d.data)), io.ErrUnexpectedEOF)
+	}
+	return nil, d.errorAt(d.dec.InputOffset(), err)
+}
+
+func (d *jsonConfigDecoder) errorAt(offset int64, err error) error {
+	line, column := position(d.data, offset)
+	return &ConfigError{Line: line, Column: column, Err: err}
+}
+
+func jsonScalar(tok json.Token) (string, bool) {
+	switch v := tok.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
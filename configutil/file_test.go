@@ -0,0 +1,207 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/spf13/pflag"
+)
+
+const testConfigJSON = `{
+  "server": {
+    "port": 9090,
+    "host": "0.0.0.0",
+    "timeout": "5"
+  },
+  "debug": true,
+  "tags": ["a", "b"],
+  "unused": null
+}
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	cf, err := LoadConfigFile(writeConfig(t, "app.json", testConfigJSON))
+	if err != nil {
+		t.Fatalf("LoadConfigFile() failed: %v", err)
+	}
+	want := map[string]string{"server.port": "9090", "server.host": "0.0.0.0", "debug": "true", "tags": "a,b"}
+	for key, value := range want {
+		if got, ok := cf.Lookup(key); !ok || got != value {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", key, got, ok, value)
+		}
+	}
+	if _, ok := cf.Lookup("unused"); ok {
+		t.Error("Lookup() of a null value should report unset")
+	}
+	if got := strings.Join(cf.Keys(), " "); got != "debug server.host server.port server.timeout tags" {
+		t.Errorf("Keys() = %q", got)
+	}
+
+	_, err = Resolve([]Source{FromFile(cf, "server.timeout")}, time.ParseDuration)
+	if want := filepath.Base(cf.Path()) + ":5:16: invalid value \"5\" for config key server.timeout"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Resolve() error = %v, want it to contain %q", err, want)
+	}
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"syntax.json", "{\n  \"port\": 80,\n  \"host\" \"x\"\n}", ":3:10: invalid character '\"' after object key"},
+		{"truncated.json", "{\"port\": ", ":1:10: unexpected EOF"},
+		{"array.json", "[1]", ":1:1: config file must hold a JSON object"},
+		{"nested.json", "{\"list\": [{\"a\": 1}]}", ":1:11: list: arrays may only hold"},
+		{"app.toml", "port = 80", "unsupported config file format"},
+	}
+	for _, tt := range tests {
+		_, err := LoadConfigFile(writeConfig(t, tt.name, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadConfigFile(%s) error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := LoadConfigFile("../app.json"); err == nil {
+		t.Error("LoadConfigFile() should reject path traversal")
+	}
+	if _, err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadConfigFile() should reject a missing file")
+	}
+}
+
+func TestRegisterConfigFormat(t *testing.T) {
+	RegisterConfigFormat("kv", func(data []byte) (map[string]any, error) {
+		doc := map[string]any{}
+		for i, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, &ConfigError{Line: i + 1, Err: errors.New("expected key=value")}
+			}
+			section, name, nested := strings.Cut(key, ".")
+			if !nested {
+				doc[key] = value
+				continue
+			}
+			m, _ := doc[section].(map[string]any)
+			if m == nil {
+				m = map[string]any{}
+				doc[section] = m
+			}
+			m[name] = value
+		}
+		return doc, nil
+	})
+
+	cf, err := LoadConfigFile(writeConfig(t, "app.KV", "server.port=7070\nname=demo"))
+	if err != nil {
+		t.Fatalf("LoadConfigFile() failed: %v", err)
+	}
+	if got, _ := cf.Lookup("server.port"); got != "7070" {
+		t.Errorf("Lookup(server.port) = %q, want 7070", got)
+	}
+	path := writeConfig(t, "bad.kv", "a=1\noops")
+	if _, err := LoadConfigFile(path); err == nil || err.Error() != path+":2: expected key=value" {
+		t.Errorf("LoadConfigFile() error = %v, want the decoder's line", err)
+	}
+}
+
+func TestFromFile_Priority(t *testing.T) {
+	cf, err := LoadConfigFile(writeConfig(t, "app.json", testConfigJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 0, "port")
+	fs.String("host", "", "host")
+	if err := fs.Parse([]string{"-host", "cli.example"}); err != nil {
+		t.Fatal(err)
+	}
+	unsetEnv(t, "TEST_PORT")
+	t.Setenv("TEST_DEBUG", "false")
+
+	sources := func(flagName, envKey, fileKey, defaultValue string) []Source {
+		return []Source{FromFlag(fs, flagName), FromEnv(envKey, true), FromFile(cf, fileKey), FromDefault(defaultValue)}
+	}
+	r, err := ResolveDetailed(sources("port", "TEST_PORT", "server.port", "8080"), strconv.Atoi)
+	if err != nil || r.Value != 9090 || r.Source != flagutil.SourceFile || r.Key != "server.port" {
+		t.Errorf("ResolveDetailed() = %+v, %v, want 9090 from the file", r, err)
+	}
+	if got, _ := Resolve(sources("host", "TEST_HOST", "server.host", "localhost"), parseString); got != "cli.example" {
+		t.Errorf("Resolve() = %q, want the CLI value", got)
+	}
+	if got, _ := Resolve(sources("debug", "TEST_DEBUG", "debug", "true"), strconv.ParseBool); got {
+		t.Error("Resolve() = true, want ENV to win over the file")
+	}
+	_, err = Resolve(sources("timeout", "TEST_TIMEOUT", "server.timeout", "1s"), time.ParseDuration, WithFallback(FallbackError))
+	if err == nil || !strings.Contains(err.Error(), "app.json:5:16: invalid value \"5\" for config key server.timeout") {
+		t.Errorf("Resolve() error = %v, want the file position", err)
+	}
+
+	// Without WithConfigFile the Resolve* wrappers never read a config file.
+	if got := ResolveInt(fs, "port", "TEST_PORT", 8080, false); got != 8080 {
+		t.Errorf("ResolveInt() = %d, want the default", got)
+	}
+}
+
+func TestWithConfigFile(t *testing.T) {
+	cf, err := LoadConfigFile(writeConfig(t, "app.json", testConfigJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 0, "port")
+	fs.String("host", "", "host")
+	if err := fs.Parse([]string{"-host", "cli.example"}); err != nil {
+		t.Fatal(err)
+	}
+	unsetEnv(t, "TEST_PORT")
+	unsetEnv(t, "TEST_TAGS")
+	t.Setenv("TEST_DEBUG", "false")
+
+	if got := ResolveInt(fs, "port", "TEST_PORT", 8080, false, WithConfigFile(cf, "server.port")); got != 9090 {
+		t.Errorf("ResolveInt() = %d, want 9090 from the file", got)
+	}
+	if got, err := ResolvePort(fs, "port", "TEST_PORT", 8080, WithConfigFile(cf, "server.port")); err != nil || got != 9090 {
+		t.Errorf("ResolvePort() = %d, %v, want 9090 from the file", got, err)
+	}
+	if got := ResolveString(fs, "host", "TEST_HOST", "localhost", true, WithConfigFile(cf, "server.host")); got != "cli.example" {
+		t.Errorf("ResolveString() = %q, want the CLI value", got)
+	}
+	if got := ResolveBool(fs, "debug", "TEST_DEBUG", true, WithConfigFile(cf, "debug")); got {
+		t.Error("ResolveBool() = true, want ENV to win over the file")
+	}
+	if got := ResolveString(fs, "missing", "TEST_MISSING", "fallback", true, WithConfigFile(cf, "server.missing")); got != "fallback" {
+		t.Errorf("ResolveString() = %q, want the default for a missing key", got)
+	}
+	if got := ResolveStringSlice(fs, "tags", "TEST_TAGS", nil, ";", WithConfigFile(cf, "tags")); strings.Join(got, " ") != "a b" {
+		t.Errorf("ResolveStringSlice() = %q, want the file array", got)
+	}
+	if got := ResolveInt(fs, "port", "TEST_PORT", 8080, false, WithConfigFile(nil, "server.port")); got != 8080 {
+		t.Errorf("ResolveInt() with a nil file = %d, want the default", got)
+	}
+
+	pfs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	pfs.String("bind", "", "bind")
+	if got, err := ResolveIPPflag(pfs, "bind", "", "127.0.0.1", WithConfigFile(cf, "server.host")); err != nil || got.String() != "0.0.0.0" {
+		t.Errorf("ResolveIPPflag() = %v, %v, want 0.0.0.0 from the file", got, err)
+	}
+
+	_, err = ResolveDurationStrict(fs, "timeout", "TEST_TIMEOUT", time.Second, WithConfigFile(cf, "server.timeout"))
+	if err == nil || !strings.Contains(err.Error(), "app.json:5:16: invalid value \"5\" for config key server.timeout") {
+		t.Errorf("ResolveDurationStrict() error = %v, want the file position", err)
+	}
+}
@@ -0,0 +1,23 @@
+package configutil
+
+import "github.com/soulteary/cli-kit/env"
+
+// EnvWithLegacy returns the environment variable a setting should be resolved
+// from, for settings whose variable was renamed. If key is set it is returned;
+// otherwise the first non-empty legacy key is returned and a warning naming
+// key as the replacement is reported (see SetWarningHandler). When none is set
+// key is returned. Pass the result as envKey to any Resolve function:
+//
+//	port := ResolveInt(fs, "port", EnvWithLegacy("APP_PORT", "PORT"), 8080, false)
+func EnvWithLegacy(key string, legacy ...string) string {
+	if env.Get(key, "") != "" {
+		return key
+	}
+	for _, old := range legacy {
+		if old != "" && env.Get(old, "") != "" {
+			warnf("environment variable %s is deprecated, use %s instead", old, key)
+			return old
+		}
+	}
+	return key
+}
@@ -0,0 +1,67 @@
+package configutil
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestEnvWithLegacy(t *testing.T) {
+	var warned []string
+	previous := SetWarningHandler(func(msg string) { warned = append(warned, msg) })
+	defer SetWarningHandler(previous)
+
+	t.Setenv("APP_PORT", "")
+	t.Setenv("PORT", "")
+	t.Setenv("SERVER_PORT", "")
+	if got := EnvWithLegacy("APP_PORT", "PORT", "SERVER_PORT"); got != "APP_PORT" || len(warned) != 0 {
+		t.Errorf("EnvWithLegacy() with nothing set = %q, warnings %q", got, warned)
+	}
+
+	t.Setenv("SERVER_PORT", "9090")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 8080, "port")
+	if got := ResolveInt(fs, "port", EnvWithLegacy("APP_PORT", "PORT", "SERVER_PORT"), 8080, false); got != 9090 {
+		t.Errorf("ResolveInt() with legacy env = %d, want 9090", got)
+	}
+	want := "environment variable SERVER_PORT is deprecated, use APP_PORT instead"
+	if len(warned) != 1 || warned[0] != want {
+		t.Errorf("warnings = %q, want [%q]", warned, want)
+	}
+
+	warned = nil
+	t.Setenv("APP_PORT", "7070")
+	if got := EnvWithLegacy("APP_PORT", "PORT", "SERVER_PORT"); got != "APP_PORT" || len(warned) != 0 {
+		t.Errorf("EnvWithLegacy() with current key set = %q, warnings %q", got, warned)
+	}
+}
+
+func TestSetWarningHandler_Default(t *testing.T) {
+	var out bytes.Buffer
+	saved := warningOutput
+	warningOutput = &out
+	defer func() { warningOutput = saved }()
+
+	// No handler installed: deprecations go to stderr, lenient warnings nowhere.
+	t.Setenv("NEW_KEY", "")
+	t.Setenv("OLD_KEY", "x")
+	if got := EnvWithLegacy("NEW_KEY", "OLD_KEY"); got != "OLD_KEY" {
+		t.Errorf("EnvWithLegacy() = %q, want OLD_KEY", got)
+	}
+	t.Setenv("TEST_TIMEOUT", "5")
+	ResolveDuration(flag.NewFlagSet("test", flag.ContinueOnError), "timeout", "TEST_TIMEOUT", time.Second)
+	if want := "warning: environment variable OLD_KEY is deprecated, use NEW_KEY instead\n"; out.String() != want {
+		t.Errorf("default warning output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	previous := SetWarningHandler(nil)
+	defer SetWarningHandler(previous)
+	if got := EnvWithLegacy("NEW_KEY", "OLD_KEY"); got != "OLD_KEY" {
+		t.Errorf("EnvWithLegacy() = %q, want OLD_KEY", got)
+	}
+	if out.Len() != 0 {
+		t.Errorf("a nil handler should discard warnings, got %q", out.String())
+	}
+}
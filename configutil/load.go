@@ -0,0 +1,527 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// ErrRequired is returned by Load for a field tagged validate:"required" that
+// no source sets.
+var ErrRequired = fmt.Errorf("required setting is not set")
+
+// LoadOptions configures Load.
+type LoadOptions struct {
+	// Args are the arguments to parse; nil means os.Args[1:].
+	Args []string
+	// EnvPrefix is prepended to env tags, and fields without an env tag are
+	// bound to flagutil.EnvName(EnvPrefix, flag) when it is set.
+	EnvPrefix string
+	// ConfigFile is looked up between ENV and default, under each field's
+	// config tag or, without one, its flag name (or env name for env-only
+	// fields). nil leaves the file layer out.
+	ConfigFile *ConfigFile
+	// Recorder records every field for Explain; nil uses the Recorder
+	// installed with SetRecorder, if any.
+	Recorder *Recorder
+}
+
+// loadField is one tagged field of the config struct.
+type loadField struct {
+	value      reflect.Value
+	flag       string
+	env        string
+	def        string
+	hasDefault bool
+	desc       string
+	configKey  string
+	secret     bool
+	validate   []fieldValidator
+	required   bool
+	cli        Source
+}
+
+// Load fills the struct cfg points to from struct tags. For every field with
+// a flag tag it defines a flag on fs, then parses opts.Args and resolves each
+// field with priority CLI flag > environment variable > config file > default:
+//
+//	type Config struct {
+//		Port    int           `flag:"port" env:"PORT" default:"8080" validate:"port" desc:"listen port"`
+//		Timeout time.Duration `flag:"timeout" default:"30s"`
+//		Token   string        `flag:"token" env:"API_TOKEN" secret:"true" validate:"required"`
+//		Server  struct {
+//			Host string `flag:"host" default:"localhost"` // flag "server.host", env SERVER_HOST
+//		} `flag:"server" env:"SERVER"`
+//	}
+//
+// Supported field types are string, bool, ints, uints, floats, time.Duration
+// and []string (comma-separated); nested structs prefix their fields' flag
+// names with "name." and env names with "NAME_". A field with only an env or
+// config tag gets no flag. A config tag sets the dotted key the field is read
+// from in LoadOptions.ConfigFile, e.g. config:"database.url"; it is not
+// prefixed. Secret fields are read with flagutil.SecretValue (so @file,
+// env:VAR and fd:N work) and redacted by Explain.
+//
+// The validate tag holds comma-separated checks from the validator package:
+// required, port, hostport, listen, url, email, ip, cidr, path, file, dir,
+// positive, nonnegative, range=MIN:MAX and enum=a|b|c. Unlike the Resolve*
+// functions, Load does not fall back past an invalid value: every invalid or
+// missing value is reported, joined into one error.
+func Load(cfg any, fs *flag.FlagSet, opts *LoadOptions) error {
+	fields, opts, err := loadFields(cfg, opts)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+		if f.secret {
+			secret := flagutil.NewSecretValue(f.flag)
+			fs.Var(secret, f.flag, f.usage())
+			f.cli = secretSource(f.flag, secret, func() bool { return flagutil.HasFlag(fs, f.flag) })
+			continue
+		}
+		fs.Var(newFieldFlag(f), f.flag, f.usage())
+		f.cli = FromFlag(fs, f.flag)
+	}
+	if err := flagutil.Parse(fs, argsOrDefault(opts.Args)); err != nil {
+		return err
+	}
+	return resolveFields(fields, opts)
+}
+
+// LoadPflag is Load for a pflag.FlagSet.
+func LoadPflag(cfg any, fs *pflag.FlagSet, opts *LoadOptions) error {
+	fields, opts, err := loadFields(cfg, opts)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+		if f.secret {
+			secret := flagutil.NewSecretValue(f.flag)
+			fs.Var(secret, f.flag, f.usage())
+			f.cli = secretSource(f.flag, secret, func() bool { return flagutil.HasFlagPflag(fs, f.flag) })
+			continue
+		}
+		fs.Var(newFieldFlag(f), f.flag, f.usage())
+		if f.value.Kind() == reflect.Bool {
+			fs.Lookup(f.flag).NoOptDefVal = "true"
+		}
+		f.cli = FromFlagPflag(fs, f.flag)
+	}
+	if err := fs.Parse(argsOrDefault(opts.Args)); err != nil {
+		return err
+	}
+	return resolveFields(fields, opts)
+}
+
+func argsOrDefault(args []string) []string {
+	if args == nil {
+		return os.Args[1:]
+	}
+	return args
+}
+
+// usage is the flag usage: desc plus the env annotation flagutil.BindEnv uses.
+func (f *loadField) usage() string {
+	if f.env == "" {
+		return f.desc
+	}
+	return f.desc + " [env: " + f.env + "]"
+}
+
+// secretSource reads the secret itself rather than its redacted String.
+func secretSource(name string, secret *flagutil.SecretValue, given func() bool) Source {
+	return Source{Kind: flagutil.SourceCLI, Key: name, secret: true, lookup: func() (string, bool) {
+		if !given() {
+			return "", false
+		}
+		return secret.Value(), true
+	}}
+}
+
+func loadFields(cfg any, opts *LoadOptions) ([]*loadField, *LoadOptions, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("configutil: Load needs a non-nil pointer to a struct, got %T", cfg)
+	}
+	var fields []*loadField
+	if err := collectFields(&fields, rv.Elem(), "", opts.EnvPrefix); err != nil {
+		return nil, nil, err
+	}
+	return fields, opts, nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+func collectFields(fields *[]*loadField, v reflect.Value, flagPrefix, envPrefix string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		flagName, hasFlag := sf.Tag.Lookup("flag")
+		envName, hasEnv := sf.Tag.Lookup("env")
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			nestedFlag, nestedEnv := flagPrefix, envPrefix
+			if hasFlag {
+				nestedFlag += flagName + "."
+			}
+			if hasEnv {
+				nestedEnv = flagutil.EnvName(envPrefix, envName)
+			}
+			if err := collectFields(fields, v.Field(i), nestedFlag, nestedEnv); err != nil {
+				return err
+			}
+			continue
+		}
+		configKey := sf.Tag.Get("config")
+		if !hasFlag && !hasEnv && configKey == "" {
+			continue
+		}
+		if !supportedField(sf.Type) {
+			return fmt.Errorf("configutil: field %s: unsupported type %s", sf.Name, sf.Type)
+		}
+		f := &loadField{value: v.Field(i), desc: sf.Tag.Get("desc"), configKey: configKey}
+		if hasFlag && flagName != "" {
+			f.flag = flagPrefix + flagName
+		}
+		switch {
+		case envName == "-":
+		case hasEnv && envName != "":
+			f.env = flagutil.EnvName(envPrefix, envName)
+		case envPrefix != "" && f.flag != "":
+			f.env = flagutil.EnvName(envPrefix, strings.TrimPrefix(f.flag, flagPrefix))
+		}
+		f.def, f.hasDefault = sf.Tag.Lookup("default")
+		f.secret, _ = strconv.ParseBool(sf.Tag.Get("secret"))
+		if f.secret && sf.Type.Kind() != reflect.String {
+			return fmt.Errorf("configutil: field %s: secret fields must be strings", sf.Name)
+		}
+		if err := f.parseValidate(sf.Tag.Get("validate")); err != nil {
+			return fmt.Errorf("configutil: field %s: %w", sf.Name, err)
+		}
+		if f.hasDefault {
+			if _, err := parseField(f.value.Type(), f.def); err != nil {
+				return fmt.Errorf("configutil: field %s: invalid default %q: %w", sf.Name, f.def, err)
+			}
+		}
+		*fields = append(*fields, f)
+	}
+	return nil
+}
+
+func resolveFields(fields []*loadField, opts *LoadOptions) error {
+	var errs []error
+	for _, f := range fields {
+		name := f.name()
+		sources := []Source{f.cli, FromEnv(f.env, true)}
+		if opts.ConfigFile != nil {
+			sources = append(sources, FromFile(opts.ConfigFile, f.fileKey()))
+		}
+		if f.hasDefault {
+			sources = append(sources, FromDefault(f.def))
+		}
+		parse := func(raw string) (reflect.Value, error) {
+			v, err := parseField(f.value.Type(), raw)
+			if err != nil {
+				return v, err
+			}
+			for _, check := range f.validate {
+				if err := check(raw, v); err != nil {
+					return v, err
+				}
+			}
+			return v, nil
+		}
+		resolveOpts := []Option{WithFallback(FallbackError), WithName(name)}
+		if f.secret {
+			resolveOpts = append(resolveOpts, WithSecret())
+		}
+		if opts.Recorder != nil {
+			resolveOpts = append(resolveOpts, WithRecorder(opts.Recorder))
+		}
+		v, err := Resolve(sources, parse, resolveOpts...)
+		switch {
+		case errors.Is(err, ErrNoValue):
+			if f.required {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrRequired, f.describe()))
+			}
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			f.value.Set(v)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fileKey is the key the field is read from in a config file.
+func (f *loadField) fileKey() string {
+	if f.configKey != "" {
+		return f.configKey
+	}
+	return f.name()
+}
+
+// describe names the field by its flag and env variable for errors.
+func (f *loadField) describe() string {
+	switch {
+	case f.flag != "" && f.env != "":
+		return "--" + f.flag + " or " + f.env
+	case f.flag != "":
+		return "--" + f.flag
+	}
+	return f.env
+}
+
+func supportedField(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// parseField parses raw into a new value of type t.
+func parseField(t reflect.Type, raw string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch {
+	case t == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	case t.Kind() == reflect.String:
+		v.SetString(raw)
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(raw, 10, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case v.CanUint():
+		n, err := strconv.ParseUint(raw, 10, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(n)
+	case v.CanFloat():
+		n, err := strconv.ParseFloat(raw, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(n)
+	case t.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(t))
+	}
+	return v, nil
+}
+
+// formatField is the inverse of parseField.
+func formatField(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return ""
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Convert(reflect.TypeFor[[]string]()).Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// fieldFlag is the flag value Load defines for a field. It holds its own
+// copy of the value; the field is only set once every source is resolved.
+type fieldFlag struct {
+	value reflect.Value
+	set   bool
+}
+
+func newFieldFlag(f *loadField) *fieldFlag {
+	v := reflect.New(f.value.Type()).Elem()
+	if f.hasDefault {
+		parsed, _ := parseField(v.Type(), f.def)
+		v.Set(parsed)
+	}
+	return &fieldFlag{value: v}
+}
+
+// Set parses s; repeating a []string flag appends.
+func (f *fieldFlag) Set(s string) error {
+	v, err := parseField(f.value.Type(), s)
+	if err != nil {
+		return err
+	}
+	if f.value.Kind() == reflect.Slice && f.set {
+		v = reflect.AppendSlice(f.value, v)
+	}
+	f.value.Set(v)
+	f.set = true
+	return nil
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return formatField(f.value)
+}
+
+// Type returns the pflag type name.
+func (f *fieldFlag) Type() string {
+	switch {
+	case f.value.Type() == durationType:
+		return "duration"
+	case f.value.Kind() == reflect.Slice:
+		return "stringSlice"
+	}
+	return f.value.Kind().String()
+}
+
+// IsBoolFlag lets bool fields be given as a bare flag.
+func (f *fieldFlag) IsBoolFlag() bool { return f.value.Kind() == reflect.Bool }
+
+// fieldValidator checks a parsed value; raw is the text it was parsed from.
+type fieldValidator func(raw string, v reflect.Value) error
+
+// parseValidate reads the validate tag.
+func (f *loadField) parseValidate(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			f.required = true
+			continue
+		}
+		check, err := validatorFor(name, arg)
+		if err != nil {
+			return err
+		}
+		f.validate = append(f.validate, check)
+	}
+	return nil
+}
+
+func validatorFor(name, arg string) (fieldValidator, error) {
+	switch name {
+	case "port":
+		return func(raw string, _ reflect.Value) error {
+			_, err := validator.ValidatePortString(raw)
+			return err
+		}, nil
+	case "hostport":
+		return func(raw string, _ reflect.Value) error {
+			_, _, err := validator.ValidateHostPort(raw)
+			return err
+		}, nil
+	case "listen":
+		return stringCheck(flagutil.ParseListenAddr), nil
+	case "url":
+		return func(raw string, _ reflect.Value) error { return validator.ValidateURL(raw, nil) }, nil
+	case "email":
+		return func(raw string, _ reflect.Value) error { return validator.ValidateEmail(raw, nil) }, nil
+	case "ip":
+		return stringCheck(flagutil.ParseIP), nil
+	case "cidr":
+		return stringCheck(flagutil.ParsePrefix), nil
+	case "path":
+		return stringCheck(func(raw string) (string, error) { return validator.ValidatePath(raw, nil) }), nil
+	case "file":
+		return func(raw string, _ reflect.Value) error { return validator.ValidateFileReadable(raw) }, nil
+	case "dir":
+		return func(raw string, _ reflect.Value) error { return validator.ValidateDirExists(raw) }, nil
+	case "positive", "nonnegative":
+		return func(_ string, v reflect.Value) error {
+			n, err := numberOf(v)
+			if err != nil {
+				return err
+			}
+			if name == "positive" && n <= 0 {
+				return fmt.Errorf("value must be positive")
+			}
+			if n < 0 {
+				return fmt.Errorf("value must be non-negative")
+			}
+			return nil
+		}, nil
+	case "range":
+		lo, hi, ok := strings.Cut(arg, ":")
+		minValue, err1 := strconv.ParseFloat(lo, 64)
+		maxValue, err2 := strconv.ParseFloat(hi, 64)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid range %q, want MIN:MAX", arg)
+		}
+		return func(_ string, v reflect.Value) error {
+			n, err := numberOf(v)
+			if err != nil {
+				return err
+			}
+			if n < minValue || n > maxValue {
+				return fmt.Errorf("value must be between %s and %s", lo, hi)
+			}
+			return nil
+		}, nil
+	case "enum":
+		allowed := strings.Split(arg, "|")
+		return func(raw string, _ reflect.Value) error { return validator.ValidateEnum(raw, allowed, true) }, nil
+	}
+	return nil, fmt.Errorf("unknown validator %q", name)
+}
+
+func stringCheck[T any](parse func(string) (T, error)) fieldValidator {
+	return func(raw string, _ reflect.Value) error {
+		_, err := parse(raw)
+		return err
+	}
+}
+
+func numberOf(v reflect.Value) (float64, error) {
+	switch {
+	case v.CanInt():
+		return float64(v.Int()), nil
+	case v.CanUint():
+		return float64(v.Uint()), nil
+	case v.CanFloat():
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("numeric validator used on %s", v.Type())
+}
@@ -0,0 +1,176 @@
+package configutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/spf13/pflag"
+)
+
+type loadTestConfig struct {
+	Port    int           `flag:"port" env:"PORT" default:"8080" validate:"port" desc:"listen port"`
+	Debug   bool          `flag:"debug" env:"DEBUG"`
+	Timeout time.Duration `flag:"timeout" default:"30s" desc:"request timeout"`
+	Tags    []string      `flag:"tags" env:"TAGS"`
+	Ratio   float64       `flag:"ratio" default:"0.5" validate:"range=0:1"`
+	Mode    string        `flag:"mode" default:"fast" validate:"enum=fast|safe"`
+	Token   string        `flag:"token" env:"TOKEN" secret:"true" desc:"API token"`
+	Region  string        `env:"REGION" default:"eu"`
+	Server  struct {
+		Host string `flag:"host" env:"HOST" default:"localhost"`
+		Port uint16 `flag:"port" default:"9000"`
+	} `flag:"server" env:"SERVER"`
+	internal string
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_TAGS", "a, b")
+	t.Setenv("APP_TOKEN", "s3cr3t")
+	t.Setenv("APP_SERVER_HOST", "env.example")
+	unsetEnv(t, "APP_DEBUG")
+	unsetEnv(t, "APP_REGION")
+
+	var cfg loadTestConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	args := []string{"-debug", "-timeout", "5s", "-ratio", "0.25", "-server.port", "9443"}
+	if err := Load(&cfg, fs, &LoadOptions{Args: args, EnvPrefix: "APP"}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Port != 9090 || !cfg.Debug || cfg.Timeout != 5*time.Second || cfg.Ratio != 0.25 || cfg.Mode != "fast" ||
+		cfg.Token != "s3cr3t" || cfg.Region != "eu" || strings.Join(cfg.Tags, "|") != "a|b" ||
+		cfg.Server.Host != "env.example" || cfg.Server.Port != 9443 {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	if f := fs.Lookup("port"); f == nil || f.DefValue != "8080" || f.Usage != "listen port [env: APP_PORT]" {
+		t.Errorf("flag port = %+v, want default 8080 and env annotation", f)
+	}
+	if f := fs.Lookup("server.host"); f == nil || f.Usage != " [env: APP_SERVER_HOST]" {
+		t.Errorf("flag server.host = %+v, want the nested env name", f)
+	}
+	if fs.Lookup("region") != nil {
+		t.Error("Load() defined a flag for an env-only field")
+	}
+	if f := fs.Lookup("token"); f == nil || f.Value.String() == "s3cr3t" {
+		t.Error("Load() should define secret fields as redacted SecretValue flags")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	type config struct {
+		Port  int    `flag:"port" env:"LOAD_PORT" default:"8080" validate:"port"`
+		Mode  string `flag:"mode" validate:"enum=fast|safe"`
+		Token string `flag:"token" env:"LOAD_TOKEN" validate:"required"`
+	}
+	t.Setenv("LOAD_PORT", "70000")
+	unsetEnv(t, "LOAD_TOKEN")
+
+	var cfg config
+	err := Load(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: []string{"-mode", "slow"}})
+	if err == nil {
+		t.Fatal("Load() should fail")
+	}
+	for _, want := range []string{
+		`invalid value "70000" for env LOAD_PORT`,
+		`invalid value "slow" for flag --mode`,
+		"required setting is not set: --token or LOAD_TOKEN",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to contain %q", err, want)
+		}
+	}
+	if !errors.Is(err, ErrRequired) {
+		t.Error("Load() error should wrap ErrRequired")
+	}
+	if cfg.Port != 0 {
+		t.Errorf("Port = %d, want the invalid value left unset", cfg.Port)
+	}
+
+	bad := []any{
+		cfg,
+		&struct {
+			C chan int `flag:"c"`
+		}{},
+		&struct {
+			N int `flag:"n" secret:"true"`
+		}{},
+		&struct {
+			N int `flag:"n" validate:"prime"`
+		}{},
+		&struct {
+			N int `flag:"n" default:"x"`
+		}{},
+	}
+	for _, v := range bad {
+		if err := Load(v, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: []string{}}); err == nil {
+			t.Errorf("Load(%T) should fail", v)
+		}
+	}
+	if err := Load(&cfg, flag.NewFlagSet("test", flag.ContinueOnError), &LoadOptions{Args: []string{"-port", "x"}}); err == nil {
+		t.Error("Load() should fail on an unparseable flag")
+	}
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	cf, err := LoadConfigFile(writeConfig(t, "app.json", testConfigJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type config struct {
+		Debug  bool     `flag:"debug" default:"false"`
+		Tags   []string `flag:"tags"`
+		Listen string   `flag:"listen" config:"server.host"`
+		Region string   `config:"region" default:"eu"`
+		Server struct {
+			Port int    `flag:"port" default:"8080"`
+			Host string `flag:"host"`
+		} `flag:"server"`
+	}
+	var cfg config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := Load(&cfg, fs, &LoadOptions{Args: []string{"-server.host", "cli.example"}, ConfigFile: cf}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.Debug || strings.Join(cfg.Tags, ",") != "a,b" || cfg.Server.Port != 9090 || cfg.Server.Host != "cli.example" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if cfg.Listen != "0.0.0.0" || cfg.Region != "eu" || fs.Lookup("region") != nil {
+		t.Errorf("Load() with config tags = %+v", cfg)
+	}
+}
+
+func TestLoadPflag(t *testing.T) {
+	unsetEnv(t, "PF_TOKEN")
+
+	var cfg struct {
+		Verbose bool     `flag:"verbose" desc:"verbose output"`
+		Hosts   []string `flag:"host"`
+		Token   string   `flag:"token" env:"PF_TOKEN" secret:"true"`
+	}
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	args := []string{"--verbose", "--host", "a,b", "--host", "c", "--token", "hunter2"}
+	var rec Recorder
+	if err := LoadPflag(&cfg, fs, &LoadOptions{Args: args, Recorder: &rec}); err != nil {
+		t.Fatalf("LoadPflag() failed: %v", err)
+	}
+	if !cfg.Verbose || strings.Join(cfg.Hosts, ",") != "a,b,c" || cfg.Token != "hunter2" {
+		t.Errorf("LoadPflag() = %+v", cfg)
+	}
+	if got := fs.Lookup("host").Value.Type(); got != "stringSlice" {
+		t.Errorf("Type() = %q, want stringSlice", got)
+	}
+
+	var out bytes.Buffer
+	if err := rec.Explain(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "token    "+flagutil.RedactedValue) || strings.Contains(out.String(), "hunter2") {
+		t.Errorf("Explain() =\n%s\nwant the token redacted", out.String())
+	}
+}
@@ -0,0 +1,146 @@
+package configutil
+
+import (
+	"flag"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// ResolveIP resolves an IP address with priority: CLI flag > environment variable > default value.
+// An invalid CLI or ENV value falls back to the next source, like ResolveStringWithValidation.
+// The flag may be a plain string flag or one defined with flagutil.IP.
+//
+// Returns:
+//   - netip.Addr: The resolved address
+//   - error: Returns error if no source, including defaultValue, holds a valid address
+func ResolveIP(fs *flag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (netip.Addr, error) {
+	return resolveParsed(FromFlag(fs, flagName), envKey, defaultValue, flagutil.ParseIP, layers)
+}
+
+// ResolvePrefix resolves a CIDR prefix such as "10.0.0.0/8" with priority:
+// CLI flag > environment variable > default value. A bare address is read as
+// a single-address prefix. Invalid values fall back like ResolveIP.
+func ResolvePrefix(fs *flag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (netip.Prefix, error) {
+	return resolveParsed(FromFlag(fs, flagName), envKey, defaultValue, flagutil.ParsePrefix, layers)
+}
+
+// ResolveListenAddr resolves an address to listen on, such as ":8080" or
+// "[::]:443", with priority: CLI flag > environment variable > default value.
+// The result is normalized by flagutil.ParseListenAddr and can be passed to
+// net.Listen. Invalid values fall back like ResolveIP.
+func ResolveListenAddr(fs *flag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (string, error) {
+	return resolveParsed(FromFlag(fs, flagName), envKey, defaultValue, flagutil.ParseListenAddr, layers)
+}
+
+// ResolveURL resolves a URL with priority: CLI flag > environment variable > default value.
+// Each candidate, including defaultValue, is checked with validator.ValidateURL
+// using opts (nil uses the secure defaults); invalid values fall back to the
+// next source.
+//
+// Returns:
+//   - *url.URL: The resolved URL
+//   - error: Returns error if no source holds a valid URL
+func ResolveURL(fs *flag.FlagSet, flagName, envKey, defaultValue string, opts *validator.URLOptions, layers ...ChainOption) (*url.URL, error) {
+	return resolveParsed(FromFlag(fs, flagName), envKey, defaultValue, urlParser(opts), layers)
+}
+
+// ResolveIPSlice resolves a list of IP addresses with priority: CLI flag >
+// environment variable > default value. The CLI value is read element by
+// element from list flags such as flagutil.IPSlice; ENV values are split by
+// sep (default ","). A list with any invalid element falls back to the next
+// source.
+//
+// Returns:
+//   - []netip.Addr: The resolved addresses
+//   - error: Returns error if defaultValue is used and holds an invalid address
+func ResolveIPSlice(fs *flag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) ([]netip.Addr, error) {
+	return resolveSlice(FromFlag(fs, flagName), flagutil.GetStringSlice(fs, flagName, nil), envKey, defaultValue, sep, flagutil.ParseIP, layers)
+}
+
+// ResolvePrefixSlice resolves a list of CIDR prefixes, such as an allowlist,
+// with the same rules as ResolveIPSlice. Use flagutil.PrefixesContain to
+// match an address against the result.
+func ResolvePrefixSlice(fs *flag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) ([]netip.Prefix, error) {
+	return resolveSlice(FromFlag(fs, flagName), flagutil.GetStringSlice(fs, flagName, nil), envKey, defaultValue, sep, flagutil.ParsePrefix, layers)
+}
+
+// ResolveIPPflag is ResolveIP for a pflag.FlagSet. Pass an empty envKey to skip the environment.
+func ResolveIPPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (netip.Addr, error) {
+	return resolveParsed(FromFlagPflag(fs, flagName), envKey, defaultValue, flagutil.ParseIP, layers)
+}
+
+// ResolvePrefixPflag is ResolvePrefix for a pflag.FlagSet.
+func ResolvePrefixPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (netip.Prefix, error) {
+	return resolveParsed(FromFlagPflag(fs, flagName), envKey, defaultValue, flagutil.ParsePrefix, layers)
+}
+
+// ResolveListenAddrPflag is ResolveListenAddr for a pflag.FlagSet.
+func ResolveListenAddrPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (string, error) {
+	return resolveParsed(FromFlagPflag(fs, flagName), envKey, defaultValue, flagutil.ParseListenAddr, layers)
+}
+
+// ResolveURLPflag is ResolveURL for a pflag.FlagSet.
+func ResolveURLPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, opts *validator.URLOptions, layers ...ChainOption) (*url.URL, error) {
+	return resolveParsed(FromFlagPflag(fs, flagName), envKey, defaultValue, urlParser(opts), layers)
+}
+
+// ResolveIPSlicePflag is ResolveIPSlice for a pflag.FlagSet.
+func ResolveIPSlicePflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) ([]netip.Addr, error) {
+	return resolveSlice(FromFlagPflag(fs, flagName), flagutil.GetStringSlicePflag(fs, flagName, nil), envKey, defaultValue, sep, flagutil.ParseIP, layers)
+}
+
+// ResolvePrefixSlicePflag is ResolvePrefixSlice for a pflag.FlagSet.
+func ResolvePrefixSlicePflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) ([]netip.Prefix, error) {
+	return resolveSlice(FromFlagPflag(fs, flagName), flagutil.GetStringSlicePflag(fs, flagName, nil), envKey, defaultValue, sep, flagutil.ParsePrefix, layers)
+}
+
+// resolveParsed resolves a value that parse both validates and converts,
+// trying the next source when a value is rejected.
+func resolveParsed[T any](cli Source, envKey, defaultValue string, parse func(string) (T, error), layers []ChainOption) (T, error) {
+	value, err := Resolve(chain(cli, FromEnv(envKey, true), defaultValue, layers...), parse, WithFallback(strictPolicy()))
+	if err != nil {
+		var zero T
+		return zero, strictError(err)
+	}
+	return value, nil
+}
+
+func urlParser(opts *validator.URLOptions) func(string) (*url.URL, error) {
+	return func(s string) (*url.URL, error) {
+		if err := validator.ValidateURL(s, opts); err != nil {
+			return nil, err
+		}
+		return url.Parse(s)
+	}
+}
+
+// resolveSlice picks the first non-empty list whose elements all parse:
+// the CLI values, then the ENV value split by sep, then defaultValue. In
+// strict mode a list with an invalid element is reported instead.
+func resolveSlice[T any](cli Source, cliItems []string, envKey string, defaultValue []string, sep string, parse func(string) (T, error), layers []ChainOption) ([]T, error) {
+	var items []string
+	sources := listSources(chain(cli, FromEnv(envKey, false), strings.Join(defaultValue, sep), layers...),
+		func(string) []string { return cliItems }, defaultValue, sep, &items)
+	value, err := Resolve(sources, func(string) ([]T, error) { return parseAll(items, parse) }, WithFallback(strictPolicy()))
+	if err != nil {
+		return nil, strictError(err)
+	}
+	return value, nil
+}
+
+func parseAll[T any](values []string, parse func(string) (T, error)) ([]T, error) {
+	items := make([]T, 0, len(values))
+	for _, value := range values {
+		item, err := parse(value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
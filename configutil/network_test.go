@@ -0,0 +1,94 @@
+package configutil
+
+import (
+	"flag"
+	"io"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+func TestResolveIP(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagutil.IP(fs, "bind", netip.Addr{}, "bind address")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_BIND", "not-an-ip")
+	got, err := ResolveIP(fs, "bind", "TEST_BIND", "0.0.0.0")
+	if err != nil || got != netip.MustParseAddr("0.0.0.0") {
+		t.Errorf("ResolveIP() with invalid env = %v, %v, want default", got, err)
+	}
+
+	if err := fs.Parse([]string{"--bind", "fe80::1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ResolveIP(fs, "bind", "TEST_BIND", "0.0.0.0"); err != nil || got != netip.MustParseAddr("fe80::1") {
+		t.Errorf("ResolveIP() = %v, %v, want CLI value", got, err)
+	}
+
+	unsetEnv(t, "TEST_BIND")
+	empty := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := ResolveIP(empty, "bind", "TEST_BIND", ""); err == nil {
+		t.Error("ResolveIP() should fail when no source holds a valid address")
+	}
+}
+
+func TestResolveListenAddrAndURL(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flagutil.ListenAddrPflag(fs, "listen", "", "", "listen address")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_LISTEN", "9090")
+	if got, err := ResolveListenAddrPflag(fs, "listen", "TEST_LISTEN", ":8080"); err != nil || got != ":9090" {
+		t.Errorf("ResolveListenAddrPflag() = %q, %v, want :9090", got, err)
+	}
+	if got, err := ResolveListenAddrPflag(fs, "listen", "", ":8080"); err != nil || got != ":8080" {
+		t.Errorf("ResolveListenAddrPflag() with empty envKey = %q, %v, want :8080", got, err)
+	}
+
+	t.Setenv("TEST_UPSTREAM", "http://127.0.0.1:9000/v1")
+	std := flag.NewFlagSet("test", flag.ContinueOnError)
+	u, err := ResolveURL(std, "upstream", "TEST_UPSTREAM", "https://1.1.1.1", nil)
+	if err != nil || u.String() != "https://1.1.1.1" {
+		t.Errorf("ResolveURL() = %v, %v, want default (localhost blocked)", u, err)
+	}
+	u, err = ResolveURL(std, "upstream", "TEST_UPSTREAM", "https://1.1.1.1", &validator.URLOptions{AllowLocalhost: true})
+	if err != nil || u.Port() != "9000" || u.Path != "/v1" {
+		t.Errorf("ResolveURL() = %v, %v, want env value", u, err)
+	}
+}
+
+func TestResolvePrefixSlice(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagutil.PrefixSlice(fs, "allow", nil, "allowed clients")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_ALLOW", "10.0.0.0/8; 192.0.2.1")
+	got, err := ResolvePrefixSlice(fs, "allow", "TEST_ALLOW", nil, ";")
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolvePrefixSlice() from env = %v, %v, want %v", got, err, want)
+	}
+
+	if err := fs.Parse([]string{"--allow", "fd00::/8,172.16.0.0/12"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ResolvePrefixSlice(fs, "allow", "TEST_ALLOW", nil, ";")
+	want = []netip.Prefix{netip.MustParsePrefix("fd00::/8"), netip.MustParsePrefix("172.16.0.0/12")}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolvePrefixSlice() from CLI = %v, %v, want %v", got, err, want)
+	}
+
+	t.Setenv("TEST_PEERS", "192.0.2.1,bogus")
+	if _, err := ResolveIPSlicePflag(pflag.NewFlagSet("test", pflag.ContinueOnError), "peer", "TEST_PEERS", []string{"bad"}, ""); err == nil {
+		t.Error("ResolveIPSlicePflag() should fail when env and default are invalid")
+	}
+}
@@ -0,0 +1,346 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// parityCase runs the same argv and environment through a flag-based and a
+// pflag-based resolver and expects identical results.
+type parityCase struct {
+	name string
+	args []string
+	env  map[string]string
+}
+
+// parityResolver resolves one setting from either FlagSet type.
+type parityResolver struct {
+	name   string
+	define func(std *flag.FlagSet, pf *pflag.FlagSet)
+	std    func(fs *flag.FlagSet) (any, error)
+	pflag  func(fs *pflag.FlagSet) (any, error)
+}
+
+func stringFlags(names ...string) func(*flag.FlagSet, *pflag.FlagSet) {
+	return func(std *flag.FlagSet, pf *pflag.FlagSet) {
+		for _, name := range names {
+			std.String(name, "", name)
+			pf.String(name, "", name)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestResolverParity(t *testing.T) {
+	positive := func(v int64) error {
+		if v <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	}
+	isLower := func(s string) bool { return s == strings.ToLower(s) }
+	localURLs := &validator.URLOptions{AllowLocalhost: true}
+	notEmpty := func(s string) error {
+		if s == "" {
+			return errors.New("empty")
+		}
+		return nil
+	}
+
+	resolvers := []parityResolver{
+		{
+			name:   "String",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveString(fs, "v", "PARITY_V", "def", true), nil },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveStringPflag(fs, "v", "PARITY_V", "def", true), nil },
+		},
+		{
+			name:   "StringNonEmpty",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveStringNonEmpty(fs, "v", "PARITY_V", "def", true), nil
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveStringNonEmptyPflag(fs, "v", "PARITY_V", "def", true), nil
+			},
+		},
+		{
+			name:   "StringWithValidator",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveStringWithValidator(fs, "v", "PARITY_V", "def", false, isLower), nil
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveStringWithValidatorPflag(fs, "v", "PARITY_V", "def", false, isLower), nil
+			},
+		},
+		{
+			name:   "StringWithValidation",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveStringWithValidation(fs, "v", "PARITY_V", "", true, notEmpty)
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveStringWithValidationPflag(fs, "v", "PARITY_V", "", true, notEmpty)
+			},
+		},
+		{
+			name:   "Int",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveInt(fs, "v", "PARITY_V", 7, false), nil },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveIntPflag(fs, "v", "PARITY_V", 7, false), nil },
+		},
+		{
+			name:   "Int64",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveInt64(fs, "v", "PARITY_V", 7, true), nil },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveInt64Pflag(fs, "v", "PARITY_V", 7, true), nil },
+		},
+		{
+			name:   "Int64WithValidation",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveInt64WithValidation(fs, "v", "PARITY_V", 7, false, positive)
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveInt64WithValidationPflag(fs, "v", "PARITY_V", 7, false, positive)
+			},
+		},
+		{
+			name:   "Port",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolvePort(fs, "v", "PARITY_V", 8080) },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolvePortPflag(fs, "v", "PARITY_V", 8080) },
+		},
+		{
+			name:   "Bool",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveBool(fs, "v", "PARITY_V", true), nil },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveBoolPflag(fs, "v", "PARITY_V", true), nil },
+		},
+		{
+			name:   "Duration",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveDuration(fs, "v", "PARITY_V", time.Second), nil },
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveDurationPflag(fs, "v", "PARITY_V", time.Second), nil
+			},
+		},
+		{
+			name:   "Enum",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveEnum(fs, "v", "PARITY_V", "a", []string{"a", "b"}, false)
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveEnumPflag(fs, "v", "PARITY_V", "a", []string{"a", "b"}, false)
+			},
+		},
+		{
+			name:   "HostPort",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				host, port, err := ResolveHostPort(fs, "v", "PARITY_V", "localhost:80")
+				return fmt.Sprintf("%s|%d", host, port), err
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				host, port, err := ResolveHostPortPflag(fs, "v", "PARITY_V", "localhost:80")
+				return fmt.Sprintf("%s|%d", host, port), err
+			},
+		},
+		{
+			name:   "StringSlice",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolveStringSlice(fs, "v", "PARITY_V", []string{"def"}, ","), nil
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolveStringSlicePflag(fs, "v", "PARITY_V", []string{"def"}, ","), nil
+			},
+		},
+		{
+			name:   "IP",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveIP(fs, "v", "PARITY_V", "127.0.0.1") },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveIPPflag(fs, "v", "PARITY_V", "127.0.0.1") },
+		},
+		{
+			name:   "Prefix",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolvePrefix(fs, "v", "PARITY_V", "") },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolvePrefixPflag(fs, "v", "PARITY_V", "") },
+		},
+		{
+			name:   "ListenAddr",
+			define: stringFlags("v"),
+			std:    func(fs *flag.FlagSet) (any, error) { return ResolveListenAddr(fs, "v", "PARITY_V", ":80") },
+			pflag:  func(fs *pflag.FlagSet) (any, error) { return ResolveListenAddrPflag(fs, "v", "PARITY_V", ":80") },
+		},
+		{
+			name:   "URL",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				u, err := ResolveURL(fs, "v", "PARITY_V", "http://localhost", localURLs)
+				return fmt.Sprint(u), err
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				u, err := ResolveURLPflag(fs, "v", "PARITY_V", "http://localhost", localURLs)
+				return fmt.Sprint(u), err
+			},
+		},
+		{
+			name:   "PrefixSlice",
+			define: stringFlags("v"),
+			std: func(fs *flag.FlagSet) (any, error) {
+				return ResolvePrefixSlice(fs, "v", "PARITY_V", []string{"10.0.0.0/8"}, ",")
+			},
+			pflag: func(fs *pflag.FlagSet) (any, error) {
+				return ResolvePrefixSlicePflag(fs, "v", "PARITY_V", []string{"10.0.0.0/8"}, ",")
+			},
+		},
+	}
+
+	cases := []parityCase{
+		{name: "nothing set"},
+		{name: "CLI value", args: []string{"--v", "9"}},
+		{name: "CLI empty", args: []string{"--v", ""}},
+		{name: "CLI blank", args: []string{"--v", "  "}},
+		{name: "CLI invalid", args: []string{"--v", "Not-Valid"}},
+		{name: "CLI over ENV", args: []string{"--v", "b"}, env: map[string]string{"PARITY_V": "3"}},
+		{name: "ENV value", env: map[string]string{"PARITY_V": "b"}},
+		{name: "ENV number", env: map[string]string{"PARITY_V": "42"}},
+		{name: "ENV zero", env: map[string]string{"PARITY_V": "0"}},
+		{name: "ENV negative", env: map[string]string{"PARITY_V": "-5"}},
+		{name: "ENV padded", env: map[string]string{"PARITY_V": "  b  "}},
+		{name: "ENV empty", env: map[string]string{"PARITY_V": ""}},
+		{name: "ENV list", env: map[string]string{"PARITY_V": "x, y,,z"}},
+		{name: "ENV host port", env: map[string]string{"PARITY_V": "example.com:9090"}},
+		{name: "ENV duration", env: map[string]string{"PARITY_V": "5m"}},
+		{name: "ENV bool", env: map[string]string{"PARITY_V": "false"}},
+		{name: "ENV ip", env: map[string]string{"PARITY_V": " 192.0.2.1 "}},
+		{name: "ENV cidr list", env: map[string]string{"PARITY_V": "192.0.2.0/24, fd00::/8"}},
+		{name: "ENV listen", env: map[string]string{"PARITY_V": "[::]:443"}},
+		{name: "ENV url", env: map[string]string{"PARITY_V": "http://127.0.0.1:8080/x"}},
+		{name: "CLI ip", args: []string{"--v", "::1"}},
+		{name: "CLI invalid ENV valid", args: []string{"--v", "BAD"}, env: map[string]string{"PARITY_V": "b"}},
+	}
+
+	for _, r := range resolvers {
+		for _, c := range cases {
+			t.Run(r.name+"/"+c.name, func(t *testing.T) {
+				for k, v := range c.env {
+					t.Setenv(k, v)
+				}
+				if c.env == nil {
+					unsetEnv(t, "PARITY_V")
+				}
+
+				std := flag.NewFlagSet("std", flag.ContinueOnError)
+				pf := pflag.NewFlagSet("pflag", pflag.ContinueOnError)
+				r.define(std, pf)
+				if err := std.Parse(c.args); err != nil {
+					t.Fatalf("std.Parse() failed: %v", err)
+				}
+				if err := pf.Parse(c.args); err != nil {
+					t.Fatalf("pflag.Parse() failed: %v", err)
+				}
+
+				stdGot, stdErr := r.std(std)
+				pfGot, pfErr := r.pflag(pf)
+				if !reflect.DeepEqual(stdGot, pfGot) || errString(stdErr) != errString(pfErr) {
+					t.Errorf("flag = (%v, %v), pflag = (%v, %v)", stdGot, stdErr, pfGot, pfErr)
+				}
+			})
+		}
+	}
+}
+
+func TestResolveStringSlicePflag_NativeSlice(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringArray("header", nil, "header")
+	if err := fs.Parse([]string{"--header", "Accept: a, b", "--header", "X-Id: 1"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	setEnvPflag(t, "TEST_HEADERS", "A,B")
+	defer unsetEnvPflag(t, "TEST_HEADERS")
+
+	got := ResolveStringSlicePflag(fs, "header", "TEST_HEADERS", nil, "")
+	want := []string{"Accept: a, b", "X-Id: 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveStringSlicePflag() = %q, want %q", got, want)
+	}
+
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringSlice("tag", nil, "tag")
+	if err := fs.Parse([]string{}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if got := ResolveStringSlicePflag(fs, "tag", "TEST_HEADERS", nil, ""); !reflect.DeepEqual(got, []string{"A", "B"}) {
+		t.Errorf("ResolveStringSlicePflag() from env = %q", got)
+	}
+	if got := ResolveStringSlicePflag(fs, "tag", "", []string{"d"}, ""); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("ResolveStringSlicePflag() with empty envKey = %q, want default", got)
+	}
+}
+
+func TestResolveInt64Pflag_EmptyEnvKey(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Int64("size", 0, "size")
+	if err := fs.Parse([]string{}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	setEnvPflag(t, "TEST_SIZE", "100")
+	defer unsetEnvPflag(t, "TEST_SIZE")
+
+	if got := ResolveInt64Pflag(fs, "size", "", 5, true); got != 5 {
+		t.Errorf("ResolveInt64Pflag() with empty envKey = %d, want 5", got)
+	}
+	if got := ResolveInt64Pflag(fs, "size", "TEST_SIZE", 5, true); got != 100 {
+		t.Errorf("ResolveInt64Pflag() = %d, want 100", got)
+	}
+	if got, err := ResolveInt64WithValidationPflag(fs, "size", "", 5, true, func(int64) error { return nil }); err != nil || got != 5 {
+		t.Errorf("ResolveInt64WithValidationPflag() with empty envKey = (%d, %v), want (5, nil)", got, err)
+	}
+	if got := ResolveStringNonEmptyPflag(fs, "size", "", "d", false); got != "d" {
+		t.Errorf("ResolveStringNonEmptyPflag() with empty envKey = %q, want d", got)
+	}
+	if got := ResolveStringWithValidatorPflag(fs, "size", "", "d", false, func(string) bool { return true }); got != "d" {
+		t.Errorf("ResolveStringWithValidatorPflag() with empty envKey = %q, want d", got)
+	}
+}
+
+func TestResolveStringNonEmptyPflag_Untrimmed(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "", "name")
+	if err := fs.Parse([]string{"--name", ""}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	setEnvPflag(t, "TEST_NAME", " env ")
+	defer unsetEnvPflag(t, "TEST_NAME")
+	if got := ResolveStringNonEmptyPflag(fs, "name", "TEST_NAME", "d", false); got != " env " {
+		t.Errorf("ResolveStringNonEmptyPflag() = %q, want untrimmed env value", got)
+	}
+
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "", "name")
+	if err := fs.Parse([]string{"--name", " cli "}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if got := ResolveStringNonEmptyPflag(fs, "name", "TEST_NAME", "d", false); got != " cli " {
+		t.Errorf("ResolveStringNonEmptyPflag() = %q, want untrimmed CLI value", got)
+	}
+}
@@ -3,11 +3,8 @@ package configutil
 import (
 	"flag"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/soulteary/cli-kit/env"
-	"github.com/soulteary/cli-kit/flagutil"
 	"github.com/soulteary/cli-kit/validator"
 )
 
@@ -20,29 +17,8 @@ import (
 //   - envKey: Name of the environment variable (e.g., "PORT")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
 //   - trimmed: If true, trim whitespace from environment variable value
-func ResolveString(fs *flag.FlagSet, flagName, envKey, defaultValue string, trimmed bool) string {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		return flagutil.GetString(fs, flagName, defaultValue)
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		if trimmed {
-			value := env.GetTrimmed(envKey, "")
-			if value != "" {
-				return value
-			}
-		} else {
-			value := env.Get(envKey, "")
-			if value != "" {
-				return value
-			}
-		}
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveString(fs *flag.FlagSet, flagName, envKey, defaultValue string, trimmed bool, layers ...ChainOption) string {
+	return resolveString(FromFlag(fs, flagName), envKey, defaultValue, trimmed, layers)
 }
 
 // ResolveInt resolves an integer configuration value with priority: CLI flag > environment variable > default value.
@@ -54,26 +30,8 @@ func ResolveString(fs *flag.FlagSet, flagName, envKey, defaultValue string, trim
 //   - envKey: Name of the environment variable (e.g., "PORT")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
 //   - allowZero: If false, zero values from ENV are treated as "not set" and default is used
-func ResolveInt(fs *flag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool) int {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetInt(fs, flagName, defaultValue)
-		// CLI flag value is always used if flag is set, even if zero
-		return value
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		value := env.GetInt(envKey, defaultValue)
-		// If allowZero is false and value is 0, treat as "not set" and use default
-		if !allowZero && value == 0 {
-			return defaultValue
-		}
-		return value
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveInt(fs *flag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) int {
+	return resolveInt(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt, layers)
 }
 
 // ResolveInt64 resolves an int64 configuration value with priority: CLI flag > environment variable > default value.
@@ -85,26 +43,8 @@ func ResolveInt(fs *flag.FlagSet, flagName, envKey string, defaultValue int, all
 //   - envKey: Name of the environment variable (e.g., "MAX_SIZE")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
 //   - allowZero: If false, zero values from ENV are treated as "not set" and default is used
-func ResolveInt64(fs *flag.FlagSet, flagName, envKey string, defaultValue int64, allowZero bool) int64 {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetInt64(fs, flagName, defaultValue)
-		// CLI flag value is always used if flag is set, even if zero
-		return value
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		value := env.GetInt64(envKey, defaultValue)
-		// If allowZero is false and value is 0, treat as "not set" and use default
-		if !allowZero && value == 0 {
-			return defaultValue
-		}
-		return value
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveInt64(fs *flag.FlagSet, flagName, envKey string, defaultValue int64, allowZero bool, layers ...ChainOption) int64 {
+	return resolveInt(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, layers)
 }
 
 // ResolveInt64WithValidation resolves an int64 configuration with custom validation function.
@@ -128,59 +68,22 @@ func ResolveInt64WithValidation(
 	defaultValue int64,
 	allowZero bool,
 	validator func(int64) error,
+	layers ...ChainOption,
 ) (int64, error) {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetInt64(fs, flagName, defaultValue)
-		if err := validator(value); err == nil {
-			return value, nil
-		}
-		// Invalid CLI value, try ENV
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		value := env.GetInt64(envKey, defaultValue)
-		if !allowZero && value == 0 {
-			// Treat as not set, try default
-		} else {
-			if err := validator(value); err == nil {
-				return value, nil
-			}
-		}
-		// Invalid ENV value, try default
-	}
-
-	// Priority 3: Default value
-	if err := validator(defaultValue); err == nil {
-		return defaultValue, nil
-	}
-
-	// All sources failed validation
-	return defaultValue, validator(defaultValue)
+	return resolveIntWithValidation(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, validator, layers)
 }
 
 // ResolveBool resolves a boolean configuration value with priority: CLI flag > environment variable > default value.
-// Returns the resolved boolean value.
+// Returns the resolved boolean value. For flags defined with flagutil.NegatableBool,
+// an explicit --no-name is a CLI value of false.
 //
 // Parameters:
 //   - fs: FlagSet to check for CLI flag
 //   - flagName: Name of the CLI flag (e.g., "redis-enabled")
 //   - envKey: Name of the environment variable (e.g., "REDIS_ENABLED")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
-func ResolveBool(fs *flag.FlagSet, flagName, envKey string, defaultValue bool) bool {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		return flagutil.GetBool(fs, flagName, defaultValue)
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		return env.GetBool(envKey, defaultValue)
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveBool(fs *flag.FlagSet, flagName, envKey string, defaultValue bool, layers ...ChainOption) bool {
+	return resolveParsedOrDefault(FromFlag(fs, flagName), envKey, defaultValue, strconv.FormatBool, strconv.ParseBool, layers)
 }
 
 // ResolveDuration resolves a duration configuration value with priority: CLI flag > environment variable > default value.
@@ -191,19 +94,44 @@ func ResolveBool(fs *flag.FlagSet, flagName, envKey string, defaultValue bool) b
 //   - flagName: Name of the CLI flag (e.g., "timeout")
 //   - envKey: Name of the environment variable (e.g., "TIMEOUT")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
-func ResolveDuration(fs *flag.FlagSet, flagName, envKey string, defaultValue time.Duration) time.Duration {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		return flagutil.GetDuration(fs, flagName, defaultValue)
-	}
+func ResolveDuration(fs *flag.FlagSet, flagName, envKey string, defaultValue time.Duration, layers ...ChainOption) time.Duration {
+	return resolveParsedOrDefault(FromFlag(fs, flagName), envKey, defaultValue, time.Duration.String, time.ParseDuration, layers)
+}
 
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		return env.GetDuration(envKey, defaultValue)
-	}
+// ResolveIntStrict is ResolveInt that reports a set value it cannot use
+// instead of falling back: an unparseable CLI or ENV value, or a
+// zero ENV value when allowZero is false (ErrZeroNotAllowed), is returned as a
+// *ValueError naming its source and raw value, together with defaultValue.
+//
+// Parameters:
+//   - fs: FlagSet to check for CLI flag
+//   - flagName: Name of the CLI flag (e.g., "port")
+//   - envKey: Name of the environment variable (e.g., "PORT")
+//   - defaultValue: Default value to use if neither CLI nor ENV is set
+//   - allowZero: If false, a zero value from ENV is an error
+func ResolveIntStrict(fs *flag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) (int, error) {
+	return resolveIntStrict(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt, layers)
+}
+
+// ResolveInt64Strict is ResolveInt64 that reports a set value it cannot use
+// (see ResolveIntStrict).
+func ResolveInt64Strict(fs *flag.FlagSet, flagName, envKey string, defaultValue int64, allowZero bool, layers ...ChainOption) (int64, error) {
+	return resolveIntStrict(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, layers)
+}
+
+// ResolveBoolStrict is ResolveBool that returns an unparseable value as a
+// *ValueError, together with defaultValue, instead of using the default.
+func ResolveBoolStrict(fs *flag.FlagSet, flagName, envKey string, defaultValue bool, layers ...ChainOption) (bool, error) {
+	sources := chain(FromFlag(fs, flagName), FromEnv(envKey, false), strconv.FormatBool(defaultValue), layers...)
+	return resolveStrict(sources, defaultValue, strconv.ParseBool)
+}
 
-	// Priority 3: Default value
-	return defaultValue
+// ResolveDurationStrict is ResolveDuration that returns an unparseable value,
+// such as TIMEOUT=5 with no unit, as a *ValueError, together with
+// defaultValue, instead of using the default.
+func ResolveDurationStrict(fs *flag.FlagSet, flagName, envKey string, defaultValue time.Duration, layers ...ChainOption) (time.Duration, error) {
+	sources := chain(FromFlag(fs, flagName), FromEnv(envKey, false), defaultValue.String(), layers...)
+	return resolveStrict(sources, defaultValue, time.ParseDuration)
 }
 
 // ResolveIntAsString resolves an integer configuration and converts it to string.
@@ -216,8 +144,8 @@ func ResolveDuration(fs *flag.FlagSet, flagName, envKey string, defaultValue tim
 //   - envKey: Name of the environment variable (e.g., "PORT")
 //   - defaultValue: Default integer value to use if neither CLI nor ENV is set
 //   - allowZero: If false, zero values from ENV are treated as "not set" and default is used
-func ResolveIntAsString(fs *flag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool) string {
-	intValue := ResolveInt(fs, flagName, envKey, defaultValue, allowZero)
+func ResolveIntAsString(fs *flag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) string {
+	intValue := ResolveInt(fs, flagName, envKey, defaultValue, allowZero, layers...)
 	return strconv.Itoa(intValue)
 }
 
@@ -237,33 +165,9 @@ func ResolveStringWithValidator(
 	flagName, envKey, defaultValue string,
 	trimmed bool,
 	validator func(string) bool,
+	layers ...ChainOption,
 ) string {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetString(fs, flagName, defaultValue)
-		if validator(value) {
-			return value
-		}
-		// Invalid CLI value, fall back to default (don't try ENV)
-		return defaultValue
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		var value string
-		if trimmed {
-			value = env.GetTrimmed(envKey, "")
-		} else {
-			value = env.Get(envKey, "")
-		}
-		if value != "" && validator(value) {
-			return value
-		}
-		// Invalid or empty ENV value, fall back to default
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+	return resolveStringWithValidator(FromFlag(fs, flagName), envKey, defaultValue, trimmed, validator, layers)
 }
 
 // ResolveStringNonEmpty resolves a string configuration, ensuring the result is non-empty.
@@ -276,39 +180,8 @@ func ResolveStringWithValidator(
 //   - envKey: Name of the environment variable
 //   - defaultValue: Default value to use
 //   - trimmed: If true, trim whitespace from environment variable value
-func ResolveStringNonEmpty(fs *flag.FlagSet, flagName, envKey, defaultValue string, trimmed bool) string {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetString(fs, flagName, defaultValue)
-		// Check if value is non-empty
-		if trimmed {
-			if strings.TrimSpace(value) != "" {
-				return value
-			}
-		} else {
-			if value != "" {
-				return value
-			}
-		}
-		// Empty CLI value, try ENV next
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		var value string
-		if trimmed {
-			value = env.GetTrimmed(envKey, "")
-		} else {
-			value = env.Get(envKey, "")
-		}
-		if value != "" {
-			return value
-		}
-		// Empty ENV value, fall back to default
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveStringNonEmpty(fs *flag.FlagSet, flagName, envKey, defaultValue string, trimmed bool, layers ...ChainOption) string {
+	return resolveStringNonEmpty(FromFlag(fs, flagName), envKey, defaultValue, trimmed, layers)
 }
 
 // ResolveStringWithValidation resolves a string configuration with custom validation function.
@@ -331,39 +204,9 @@ func ResolveStringWithValidation(
 	flagName, envKey, defaultValue string,
 	trimmed bool,
 	validator func(string) error,
+	layers ...ChainOption,
 ) (string, error) {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetString(fs, flagName, defaultValue)
-		if err := validator(value); err == nil {
-			return value, nil
-		}
-		// Invalid CLI value, try ENV
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		var value string
-		if trimmed {
-			value = env.GetTrimmed(envKey, "")
-		} else {
-			value = env.Get(envKey, "")
-		}
-		if value != "" {
-			if err := validator(value); err == nil {
-				return value, nil
-			}
-		}
-		// Invalid ENV value, try default
-	}
-
-	// Priority 3: Default value
-	if err := validator(defaultValue); err == nil {
-		return defaultValue, nil
-	}
-
-	// All sources failed validation
-	return defaultValue, validator(defaultValue)
+	return resolveStringWithValidation(FromFlag(fs, flagName), envKey, defaultValue, trimmed, validator, layers)
 }
 
 // ResolveIntWithValidation resolves an integer configuration with custom validation function.
@@ -387,36 +230,9 @@ func ResolveIntWithValidation(
 	defaultValue int,
 	allowZero bool,
 	validator func(int) error,
+	layers ...ChainOption,
 ) (int, error) {
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetInt(fs, flagName, defaultValue)
-		if err := validator(value); err == nil {
-			return value, nil
-		}
-		// Invalid CLI value, try ENV
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		value := env.GetInt(envKey, defaultValue)
-		if !allowZero && value == 0 {
-			// Treat as not set, try default
-		} else {
-			if err := validator(value); err == nil {
-				return value, nil
-			}
-		}
-		// Invalid ENV value, try default
-	}
-
-	// Priority 3: Default value
-	if err := validator(defaultValue); err == nil {
-		return defaultValue, nil
-	}
-
-	// All sources failed validation
-	return defaultValue, validator(defaultValue)
+	return resolveIntWithValidation(FromFlag(fs, flagName), envKey, defaultValue, allowZero, parseInt, validator, layers)
 }
 
 // ResolveStringSlice resolves a string slice configuration value with priority: CLI flag > environment variable > default value.
@@ -429,31 +245,16 @@ func ResolveIntWithValidation(
 //   - envKey: Name of the environment variable (e.g., "HOOKS")
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
 //   - sep: Separator for environment variable parsing (default ",")
-func ResolveStringSlice(fs *flag.FlagSet, flagName, envKey string, defaultValue []string, sep string) []string {
-	if sep == "" {
-		sep = ","
-	}
-
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) {
-		value := flagutil.GetString(fs, flagName, "")
-		if value != "" {
-			// Single value from flag, return as slice
-			// Note: For multi-value flags, the caller should use flag.Var with a custom type
-			return []string{value}
+func ResolveStringSlice(fs *flag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) []string {
+	// For multi-value flags, the caller should use flag.Var with a custom type
+	// and ResolveStringSliceMulti; the CLI value is returned as one element.
+	cliItems := func(raw string) []string {
+		if raw == "" {
+			return nil
 		}
+		return []string{raw}
 	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		result := env.GetStringSlice(envKey, nil, sep)
-		if len(result) > 0 {
-			return result
-		}
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+	return resolveStringSlice(FromFlag(fs, flagName), cliItems, envKey, defaultValue, sep, layers)
 }
 
 // ResolveStringSliceMulti resolves a string slice from a multi-value flag (flag.Value interface).
@@ -468,26 +269,9 @@ func ResolveStringSlice(fs *flag.FlagSet, flagName, envKey string, defaultValue
 //   - currentFlagValue: Current slice value from the flag (already parsed by flag.Var)
 //   - defaultValue: Default value to use if neither CLI nor ENV is set
 //   - sep: Separator for environment variable parsing (default ",")
-func ResolveStringSliceMulti(fs *flag.FlagSet, flagName, envKey string, currentFlagValue, defaultValue []string, sep string) []string {
-	if sep == "" {
-		sep = ","
-	}
-
-	// Priority 1: CLI flag (highest priority)
-	if flagutil.HasFlag(fs, flagName) && len(currentFlagValue) > 0 {
-		return currentFlagValue
-	}
-
-	// Priority 2: Environment variable
-	if env.Has(envKey) {
-		result := env.GetStringSlice(envKey, nil, sep)
-		if len(result) > 0 {
-			return result
-		}
-	}
-
-	// Priority 3: Default value
-	return defaultValue
+func ResolveStringSliceMulti(fs *flag.FlagSet, flagName, envKey string, currentFlagValue, defaultValue []string, sep string, layers ...ChainOption) []string {
+	cliItems := func(string) []string { return currentFlagValue }
+	return resolveStringSlice(FromFlag(fs, flagName), cliItems, envKey, defaultValue, sep, layers)
 }
 
 // ResolveEnum resolves an enum configuration value with validation.
@@ -510,11 +294,12 @@ func ResolveEnum(
 	flagName, envKey, defaultValue string,
 	allowedValues []string,
 	caseSensitive bool,
+	layers ...ChainOption,
 ) (string, error) {
 	validateEnum := func(s string) error {
 		return validator.ValidateEnum(s, allowedValues, caseSensitive)
 	}
-	return ResolveStringWithValidation(fs, flagName, envKey, defaultValue, true, validateEnum)
+	return ResolveStringWithValidation(fs, flagName, envKey, defaultValue, true, validateEnum, layers...)
 }
 
 // ResolveHostPort resolves a host:port configuration with validation.
@@ -534,8 +319,9 @@ func ResolveEnum(
 func ResolveHostPort(
 	fs *flag.FlagSet,
 	flagName, envKey, defaultValue string,
+	layers ...ChainOption,
 ) (host string, port int, err error) {
-	value := ResolveString(fs, flagName, envKey, defaultValue, true)
+	value := ResolveString(fs, flagName, envKey, defaultValue, true, layers...)
 	return validator.ValidateHostPort(value)
 }
 
@@ -556,9 +342,10 @@ func ResolvePort(
 	fs *flag.FlagSet,
 	flagName, envKey string,
 	defaultValue int,
+	layers ...ChainOption,
 ) (int, error) {
 	validatePort := func(port int) error {
 		return validator.ValidatePort(port)
 	}
-	return ResolveIntWithValidation(fs, flagName, envKey, defaultValue, false, validatePort)
+	return ResolveIntWithValidation(fs, flagName, envKey, defaultValue, false, validatePort, layers...)
 }
@@ -7,55 +7,41 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/soulteary/cli-kit/env"
 	"github.com/soulteary/cli-kit/flagutil"
 	"github.com/soulteary/cli-kit/validator"
 	"github.com/spf13/pflag"
 )
 
 // ResolveStringPflag resolves a string with priority: CLI flag > env (if envKey set) > default.
-func ResolveStringPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, trimmed bool) string {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		return flagutil.GetStringPflag(fs, flagName, defaultValue)
-	}
-	if envKey != "" && env.Has(envKey) {
-		if trimmed {
-			if v := env.GetTrimmed(envKey, ""); v != "" {
-				return v
-			}
-		} else {
-			if v := env.Get(envKey, ""); v != "" {
-				return v
-			}
-		}
-	}
-	return defaultValue
+func ResolveStringPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, trimmed bool, layers ...ChainOption) string {
+	return resolveString(FromFlagPflag(fs, flagName), envKey, defaultValue, trimmed, layers)
 }
 
 // ResolveIntPflag resolves an int with priority: CLI flag > env (if envKey set) > default.
-func ResolveIntPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool) int {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		return flagutil.GetIntPflag(fs, flagName, defaultValue)
-	}
-	if envKey != "" && env.Has(envKey) {
-		v := env.GetInt(envKey, defaultValue)
-		if !allowZero && v == 0 {
-			return defaultValue
-		}
-		return v
-	}
-	return defaultValue
+func ResolveIntPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) int {
+	return resolveInt(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt, layers)
+}
+
+// ResolveInt64Pflag resolves an int64 with priority: CLI flag > env (if envKey set) > default.
+func ResolveInt64Pflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int64, allowZero bool, layers ...ChainOption) int64 {
+	return resolveInt(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, layers)
+}
+
+// ResolveInt64WithValidationPflag resolves an int64 with custom validation.
+func ResolveInt64WithValidationPflag(
+	fs *pflag.FlagSet,
+	flagName, envKey string,
+	defaultValue int64,
+	allowZero bool,
+	validate func(int64) error,
+	layers ...ChainOption,
+) (int64, error) {
+	return resolveIntWithValidation(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, validate, layers)
 }
 
 // ResolveBoolPflag resolves a bool with priority: CLI flag > env (if envKey set) > default.
-func ResolveBoolPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue bool) bool {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		return flagutil.GetBoolPflag(fs, flagName, defaultValue)
-	}
-	if envKey != "" && env.Has(envKey) {
-		return env.GetBool(envKey, defaultValue)
-	}
-	return defaultValue
+func ResolveBoolPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue bool, layers ...ChainOption) bool {
+	return resolveParsedOrDefault(FromFlagPflag(fs, flagName), envKey, defaultValue, strconv.FormatBool, strconv.ParseBool, layers)
 }
 
 // ResolveEnumPflag resolves an enum string with validation.
@@ -65,11 +51,12 @@ func ResolveEnumPflag(
 	flagName, envKey, defaultValue string,
 	allowedValues []string,
 	caseSensitive bool,
+	layers ...ChainOption,
 ) (string, error) {
 	validate := func(s string) error {
 		return validator.ValidateEnum(s, allowedValues, caseSensitive)
 	}
-	return ResolveStringWithValidationPflag(fs, flagName, envKey, defaultValue, true, validate)
+	return ResolveStringWithValidationPflag(fs, flagName, envKey, defaultValue, true, validate, layers...)
 }
 
 // ResolveStringWithValidationPflag resolves a string with custom validation.
@@ -78,30 +65,26 @@ func ResolveStringWithValidationPflag(
 	flagName, envKey, defaultValue string,
 	trimmed bool,
 	validate func(string) error,
+	layers ...ChainOption,
 ) (string, error) {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		v := flagutil.GetStringPflag(fs, flagName, defaultValue)
-		if err := validate(v); err == nil {
-			return v, nil
-		}
-	}
-	if envKey != "" && env.Has(envKey) {
-		var v string
-		if trimmed {
-			v = env.GetTrimmed(envKey, "")
-		} else {
-			v = env.Get(envKey, "")
-		}
-		if v != "" {
-			if err := validate(v); err == nil {
-				return v, nil
-			}
-		}
-	}
-	if err := validate(defaultValue); err == nil {
-		return defaultValue, nil
-	}
-	return defaultValue, validate(defaultValue)
+	return resolveStringWithValidation(FromFlagPflag(fs, flagName), envKey, defaultValue, trimmed, validate, layers)
+}
+
+// ResolveStringWithValidatorPflag resolves a string with a boolean validator.
+// An invalid CLI value falls back to the default without trying env, matching ResolveStringWithValidator.
+func ResolveStringWithValidatorPflag(
+	fs *pflag.FlagSet,
+	flagName, envKey, defaultValue string,
+	trimmed bool,
+	validate func(string) bool,
+	layers ...ChainOption,
+) string {
+	return resolveStringWithValidator(FromFlagPflag(fs, flagName), envKey, defaultValue, trimmed, validate, layers)
+}
+
+// ResolveStringNonEmptyPflag resolves a string, skipping empty CLI and env values.
+func ResolveStringNonEmptyPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, trimmed bool, layers ...ChainOption) string {
+	return resolveStringNonEmpty(FromFlagPflag(fs, flagName), envKey, defaultValue, trimmed, layers)
 }
 
 // ResolveIntWithValidationPflag resolves an int with custom validation.
@@ -111,47 +94,61 @@ func ResolveIntWithValidationPflag(
 	defaultValue int,
 	allowZero bool,
 	validate func(int) error,
+	layers ...ChainOption,
 ) (int, error) {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		v := flagutil.GetIntPflag(fs, flagName, defaultValue)
-		if err := validate(v); err == nil {
-			return v, nil
-		}
-	}
-	if envKey != "" && env.Has(envKey) {
-		v := env.GetInt(envKey, defaultValue)
-		if allowZero || v != 0 {
-			if err := validate(v); err == nil {
-				return v, nil
-			}
-		}
-	}
-	if err := validate(defaultValue); err == nil {
-		return defaultValue, nil
-	}
-	return defaultValue, validate(defaultValue)
+	return resolveIntWithValidation(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt, validate, layers)
 }
 
 // ResolvePortPflag resolves a port (1-65535) with validation.
-func ResolvePortPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int) (int, error) {
+func ResolvePortPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, layers ...ChainOption) (int, error) {
 	validate := func(port int) error {
 		return validator.ValidatePort(port)
 	}
-	return ResolveIntWithValidationPflag(fs, flagName, envKey, defaultValue, false, validate)
+	return ResolveIntWithValidationPflag(fs, flagName, envKey, defaultValue, false, validate, layers...)
+}
+
+// ResolveIntStrictPflag is ResolveIntStrict for pflag.
+func ResolveIntStrictPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) (int, error) {
+	return resolveIntStrict(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt, layers)
+}
+
+// ResolveInt64StrictPflag is ResolveInt64Strict for pflag.
+func ResolveInt64StrictPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int64, allowZero bool, layers ...ChainOption) (int64, error) {
+	return resolveIntStrict(FromFlagPflag(fs, flagName), envKey, defaultValue, allowZero, parseInt64, layers)
+}
+
+// ResolveBoolStrictPflag is ResolveBoolStrict for pflag.
+func ResolveBoolStrictPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue bool, layers ...ChainOption) (bool, error) {
+	sources := chain(FromFlagPflag(fs, flagName), FromEnv(envKey, false), strconv.FormatBool(defaultValue), layers...)
+	return resolveStrict(sources, defaultValue, strconv.ParseBool)
+}
+
+// ResolveDurationStrictPflag is ResolveDurationStrict for pflag.
+func ResolveDurationStrictPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue time.Duration, layers ...ChainOption) (time.Duration, error) {
+	sources := chain(FromFlagPflag(fs, flagName), FromEnv(envKey, false), defaultValue.String(), layers...)
+	return resolveStrict(sources, defaultValue, time.ParseDuration)
 }
 
 // ResolveDurationPflag resolves a duration with priority: CLI > env (if envKey set) > default.
-func ResolveDurationPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue time.Duration) time.Duration {
-	if flagutil.HasFlagPflag(fs, flagName) {
-		return flagutil.GetDurationPflag(fs, flagName, defaultValue)
-	}
-	if envKey != "" && env.Has(envKey) {
-		return env.GetDuration(envKey, defaultValue)
-	}
-	return defaultValue
+func ResolveDurationPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue time.Duration, layers ...ChainOption) time.Duration {
+	return resolveParsedOrDefault(FromFlagPflag(fs, flagName), envKey, defaultValue, time.Duration.String, time.ParseDuration, layers)
 }
 
 // ResolveIntAsStringPflag resolves an int and returns it as string.
-func ResolveIntAsStringPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool) string {
-	return strconv.Itoa(ResolveIntPflag(fs, flagName, envKey, defaultValue, allowZero))
+func ResolveIntAsStringPflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue int, allowZero bool, layers ...ChainOption) string {
+	return strconv.Itoa(ResolveIntPflag(fs, flagName, envKey, defaultValue, allowZero, layers...))
+}
+
+// ResolveStringSlicePflag resolves a string slice with priority: CLI flag > env (if envKey set) > default.
+// Native pflag list flags (StringSlice, StringArray, ...) are read element by element;
+// env values are split by sep (default ",").
+func ResolveStringSlicePflag(fs *pflag.FlagSet, flagName, envKey string, defaultValue []string, sep string, layers ...ChainOption) []string {
+	cliItems := func(string) []string { return flagutil.GetStringSlicePflag(fs, flagName, nil) }
+	return resolveStringSlice(FromFlagPflag(fs, flagName), cliItems, envKey, defaultValue, sep, layers)
+}
+
+// ResolveHostPortPflag resolves a host:port string and validates it.
+func ResolveHostPortPflag(fs *pflag.FlagSet, flagName, envKey, defaultValue string, layers ...ChainOption) (host string, port int, err error) {
+	value := ResolveStringPflag(fs, flagName, envKey, defaultValue, true, layers...)
+	return validator.ValidateHostPort(value)
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/soulteary/cli-kit/flagutil"
 	"github.com/spf13/pflag"
 )
 
@@ -158,6 +159,18 @@ func TestResolveIntPflag(t *testing.T) {
 }
 
 func TestResolveBoolPflag(t *testing.T) {
+	t.Run("negated CLI flag overrides ENV", func(t *testing.T) {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flagutil.NegatableBoolPflag(fs, "color", "", true, "color output")
+		setEnvPflag(t, "TEST_COLOR", "true")
+		defer unsetEnvPflag(t, "TEST_COLOR")
+		if err := fs.Parse([]string{"--no-color"}); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		if got := ResolveBoolPflag(fs, "color", "TEST_COLOR", true); got {
+			t.Errorf("ResolveBoolPflag() = %v, want false", got)
+		}
+	})
 	t.Run("empty envKey CLI set", func(t *testing.T) {
 		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
 		fs.BoolP("on", "o", false, "switch")
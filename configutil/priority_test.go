@@ -6,6 +6,8 @@ import (
 	"os"
 	"testing"
 	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
 )
 
 // setEnv sets an environment variable and panics on error
@@ -411,6 +413,21 @@ func TestResolveInt64WithValidation(t *testing.T) {
 }
 
 func TestResolveBool(t *testing.T) {
+	t.Run("negated CLI flag overrides ENV", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flagutil.NegatableBool(fs, "color", true, "color output")
+		setEnv(t, "TEST_COLOR", "true")
+		defer unsetEnv(t, "TEST_COLOR")
+
+		if err := fs.Parse([]string{"--no-color"}); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+
+		if got := ResolveBool(fs, "color", "TEST_COLOR", true); got != false {
+			t.Errorf("ResolveBool() = %v, want %v", got, false)
+		}
+	})
+
 	t.Run("CLI flag has highest priority", func(t *testing.T) {
 		fs := flag.NewFlagSet("test", flag.ContinueOnError)
 		fs.Bool("test-flag", false, "test flag")
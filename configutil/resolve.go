@@ -0,0 +1,441 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/soulteary/cli-kit/env"
+	"github.com/soulteary/cli-kit/flagutil"
+	"github.com/spf13/pflag"
+)
+
+// ErrNoValue is returned by Resolve when none of the sources holds a value.
+var ErrNoValue = fmt.Errorf("no value")
+
+// FallbackPolicy decides what Resolve does with a value that parse rejects.
+type FallbackPolicy int
+
+const (
+	// FallbackNext rejects the value and tries the next source.
+	FallbackNext FallbackPolicy = iota
+	// FallbackDefault rejects the value and skips to the default source.
+	FallbackDefault
+	// FallbackError stops and returns a *ValueError.
+	FallbackError
+)
+
+// Source is one candidate for Resolve: a CLI flag, an environment variable or
+// a default, tried in the order given.
+type Source struct {
+	// Kind says where the value comes from.
+	Kind flagutil.Source
+	// Key is the flag name or environment variable; empty for defaults.
+	Key    string
+	lookup func() (string, bool)
+	secret bool
+	// where locates file values in errors, e.g. "app.json:3:12".
+	where string
+}
+
+// Lookup returns the raw value and whether the source is set.
+func (s Source) Lookup() (string, bool) {
+	if s.lookup == nil {
+		return "", false
+	}
+	return s.lookup()
+}
+
+// SkipIf returns a copy of s that reports itself unset when skip returns true
+// for its raw value, e.g. to treat PORT=0 as "not set".
+func (s Source) SkipIf(skip func(raw string) bool) Source {
+	lookup := s.lookup
+	s.lookup = func() (string, bool) {
+		raw, ok := lookup()
+		if !ok || skip(raw) {
+			return "", false
+		}
+		return raw, true
+	}
+	return s
+}
+
+// FromFlag is the value of a flag on fs, set when flagutil.HasFlag reports it
+// (an empty value counts as set).
+func FromFlag(fs *flag.FlagSet, name string) Source {
+	var secret bool
+	if fs != nil {
+		if f := fs.Lookup(name); f != nil {
+			_, secret = f.Value.(*flagutil.SecretValue)
+		}
+	}
+	return Source{Kind: flagutil.SourceCLI, Key: name, secret: secret, lookup: func() (string, bool) {
+		return flagutil.GetFlagValue(fs, name)
+	}}
+}
+
+// FromFlagPflag is FromFlag for a pflag.FlagSet.
+func FromFlagPflag(fs *pflag.FlagSet, name string) Source {
+	var secret bool
+	if fs != nil {
+		if f := fs.Lookup(name); f != nil {
+			_, secret = f.Value.(*flagutil.SecretValue)
+		}
+	}
+	return Source{Kind: flagutil.SourceCLI, Key: name, secret: secret, lookup: func() (string, bool) {
+		return flagutil.GetFlagValuePflag(fs, name)
+	}}
+}
+
+// FromEnv is the value of environment variable key, optionally trimmed.
+// An empty value, or an empty key, counts as unset.
+func FromEnv(key string, trimmed bool) Source {
+	return Source{Kind: flagutil.SourceEnv, Key: key, lookup: func() (string, bool) {
+		if key == "" {
+			return "", false
+		}
+		value := env.Get(key, "")
+		if trimmed {
+			value = strings.TrimSpace(value)
+		}
+		return value, value != ""
+	}}
+}
+
+// FromDefault is a default value, always set.
+func FromDefault(value string) Source {
+	return Source{Kind: flagutil.SourceDefault, lookup: func() (string, bool) {
+		return value, true
+	}}
+}
+
+// ValueError reports a value that parse rejected.
+type ValueError struct {
+	Kind flagutil.Source
+	Key  string
+	Raw  string
+	Err  error
+	// Where locates values read from a config file, e.g. "app.json:3:12".
+	Where string
+}
+
+func (e *ValueError) Error() string {
+	if e.Where != "" {
+		return e.Where + ": " + e.message()
+	}
+	return e.message()
+}
+
+func (e *ValueError) message() string {
+	switch e.Kind {
+	case flagutil.SourceCLI:
+		return fmt.Sprintf("invalid value %q for flag --%s: %v", e.Raw, e.Key, e.Err)
+	case flagutil.SourceEnv:
+		return fmt.Sprintf("invalid value %q for env %s: %v", e.Raw, e.Key, e.Err)
+	case flagutil.SourceFile:
+		return fmt.Sprintf("invalid value %q for config key %s: %v", e.Raw, e.Key, e.Err)
+	}
+	return fmt.Sprintf("invalid default value %q: %v", e.Raw, e.Err)
+}
+
+func (e *ValueError) Unwrap() error { return e.Err }
+
+// Option configures Resolve.
+type Option func(*resolveOptions)
+
+type resolveOptions struct {
+	fallback FallbackPolicy
+	name     string
+	secret   bool
+	recorder *Recorder
+}
+
+// WithFallback sets the FallbackPolicy; the default is FallbackNext.
+func WithFallback(policy FallbackPolicy) Option {
+	return func(o *resolveOptions) { o.fallback = policy }
+}
+
+// WithName sets the name the setting is listed under by Explain. The default
+// is the key of the first source, usually the flag name.
+func WithName(name string) Option {
+	return func(o *resolveOptions) { o.name = name }
+}
+
+// WithSecret redacts the value in Explain. Sources read from a
+// flagutil.SecretValue flag are redacted without it.
+func WithSecret() Option {
+	return func(o *resolveOptions) { o.secret = true }
+}
+
+// WithRecorder records the resolution in r for Explain, instead of the
+// Recorder installed with SetRecorder.
+func WithRecorder(r *Recorder) Option {
+	return func(o *resolveOptions) { o.recorder = r }
+}
+
+// Resolve returns the first value in sources that is set and accepted by
+// parse, which both converts and validates the raw string. A rejected value
+// is handled according to the FallbackPolicy. When every set value is
+// rejected, the last *ValueError is returned; when none is set, ErrNoValue.
+//
+//	port, err := configutil.Resolve([]configutil.Source{
+//		configutil.FromFlag(fs, "port"),
+//		configutil.FromEnv("PORT", true),
+//		configutil.FromDefault("8080"),
+//	}, strconv.Atoi)
+func Resolve[T any](sources []Source, parse func(string) (T, error), opts ...Option) (T, error) {
+	r, err := ResolveDetailed(sources, parse, opts...)
+	return r.Value, err
+}
+
+// ResolveDetailed is Resolve returning where the value came from and which
+// candidates were rejected on the way. Successful resolutions are also
+// recorded for Explain when a Recorder is given or installed.
+func ResolveDetailed[T any](sources []Source, parse func(string) (T, error), opts ...Option) (Resolved[T], error) {
+	o := resolveOptions{fallback: FallbackNext, recorder: defaultRecorder.Load()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		r             Resolved[T]
+		lastErr       error
+		skipToDefault bool
+	)
+	for _, src := range sources {
+		o.secret = o.secret || src.secret
+		if skipToDefault && src.Kind != flagutil.SourceDefault {
+			continue
+		}
+		raw, ok := src.Lookup()
+		if !ok {
+			continue
+		}
+		value, err := parse(raw)
+		if err == nil {
+			r.Value, r.Source, r.Key, r.Raw = value, src.Kind, src.Key, raw
+			if o.recorder != nil {
+				o.recorder.record(setting{name: settingName(o, sources), value: fmt.Sprint(value),
+					source: r.Source, key: r.Key, secret: o.secret, rejected: r.Rejected})
+			}
+			return r, nil
+		}
+		rejected := &ValueError{Kind: src.Kind, Key: src.Key, Raw: raw, Err: err, Where: src.where}
+		r.Rejected = append(r.Rejected, rejected)
+		lastErr = rejected
+		switch o.fallback {
+		case FallbackError:
+			return r, lastErr
+		case FallbackDefault:
+			skipToDefault = true
+		}
+	}
+	if lastErr != nil {
+		return r, lastErr
+	}
+	return r, ErrNoValue
+}
+
+// cause returns the error parse returned, for wrappers that report it as-is.
+func cause(err error) error {
+	var ve *ValueError
+	if errors.As(err, &ve) {
+		return ve.Err
+	}
+	return err
+}
+
+// The Resolve* functions are thin wrappers around the helpers below, shared
+// by the flag and pflag variants; each keeps the fallback rules it has always
+// documented.
+
+// errRejected is what a boolean validator's rejection becomes.
+var errRejected = fmt.Errorf("rejected by validator")
+
+func parseString(s string) (string, error) { return s, nil }
+
+func resolveString(cli Source, envKey, defaultValue string, trimmed bool, layers []ChainOption) string {
+	value, _ := Resolve(chain(cli, FromEnv(envKey, trimmed), defaultValue, layers...), parseString)
+	return value
+}
+
+// resolveStringNonEmpty is resolveString with empty (or, when trimmed,
+// blank) CLI values treated as unset.
+func resolveStringNonEmpty(cli Source, envKey, defaultValue string, trimmed bool, layers []ChainOption) string {
+	cli = cli.SkipIf(func(raw string) bool {
+		if trimmed {
+			raw = strings.TrimSpace(raw)
+		}
+		return raw == ""
+	})
+	return resolveString(cli, envKey, defaultValue, trimmed, layers)
+}
+
+// resolveStringWithValidator uses the default, unvalidated, as soon as a
+// value is rejected.
+func resolveStringWithValidator(cli Source, envKey, defaultValue string, trimmed bool, validate func(string) bool, layers []ChainOption) string {
+	parse := func(s string) (string, error) {
+		if !validate(s) {
+			return "", errRejected
+		}
+		return s, nil
+	}
+	value, err := Resolve(chain(cli, FromEnv(envKey, trimmed), defaultValue, layers...), parse, WithFallback(FallbackDefault))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// resolveStringWithValidation tries the next source when a value is rejected
+// (or, in strict mode, reports it) and reports the default's validation error
+// when nothing is accepted.
+func resolveStringWithValidation(cli Source, envKey, defaultValue string, trimmed bool, validate func(string) error, layers []ChainOption) (string, error) {
+	parse := func(s string) (string, error) { return s, validate(s) }
+	value, err := Resolve(chain(cli, FromEnv(envKey, trimmed), defaultValue, layers...), parse, WithFallback(strictPolicy()))
+	if err != nil {
+		return defaultValue, strictError(err)
+	}
+	return value, nil
+}
+
+// resolveInt uses the default for unparseable values and, unless allowZero,
+// treats a zero environment value as unset.
+func resolveInt[T int | int64](cli Source, envKey string, defaultValue T, allowZero bool, parse func(string) (T, error), layers []ChainOption) T {
+	envSource := FromEnv(envKey, false)
+	if !allowZero {
+		envSource = envSource.SkipIf(func(raw string) bool {
+			n, err := parse(raw)
+			return err == nil && n == 0
+		})
+	}
+	sources := chain(cli, envSource, fmt.Sprint(defaultValue), layers...)
+	r, _ := ResolveDetailed(sources, parse, WithFallback(FallbackDefault))
+	warnIgnored(r.Rejected, isSecret(sources))
+	return r.Value
+}
+
+// resolveIntWithValidation reads unparseable values as the default, then
+// tries the next source when validate rejects a value. In strict mode both
+// are errors.
+func resolveIntWithValidation[T int | int64](
+	cli Source,
+	envKey string,
+	defaultValue T,
+	allowZero bool,
+	parse func(string) (T, error),
+	validate func(T) error,
+	layers []ChainOption,
+) (T, error) {
+	if isStrict() {
+		envSource := FromEnv(envKey, false)
+		if !allowZero {
+			if err := checkZero(cli, envSource, parse); err != nil {
+				return defaultValue, err
+			}
+		}
+		check := func(s string) (T, error) {
+			n, err := parse(s)
+			if err != nil {
+				return n, err
+			}
+			return n, validate(n)
+		}
+		return resolveStrict(chain(cli, envSource, fmt.Sprint(defaultValue), layers...), defaultValue, check)
+	}
+
+	lenient := func(s string) T {
+		if n, err := parse(s); err == nil {
+			return n
+		}
+		return defaultValue
+	}
+	envSource := FromEnv(envKey, false)
+	if !allowZero {
+		envSource = envSource.SkipIf(func(raw string) bool { return lenient(raw) == 0 })
+	}
+	check := func(s string) (T, error) {
+		n := lenient(s)
+		return n, validate(n)
+	}
+	sources := chain(cli, envSource, fmt.Sprint(defaultValue), layers...)
+	r, err := ResolveDetailed(sources, check)
+	if err != nil {
+		return defaultValue, cause(err)
+	}
+	warnIfSubstituted(r, sources, parse)
+	return r.Value, nil
+}
+
+// resolveParsedOrDefault uses the default for unparseable values.
+func resolveParsedOrDefault[T any](cli Source, envKey string, defaultValue T, format func(T) string, parse func(string) (T, error), layers []ChainOption) T {
+	sources := chain(cli, FromEnv(envKey, false), format(defaultValue), layers...)
+	r, err := ResolveDetailed(sources, parse, WithFallback(FallbackDefault))
+	warnIgnored(r.Rejected, isSecret(sources))
+	if err != nil {
+		return defaultValue
+	}
+	return r.Value
+}
+
+// listSources turns the sources of chain into list sources: each is set only
+// when its list is non-empty, and the list of the source being tried is kept
+// in *items for parse. CLI values are listed by cliItems, ENV values split
+// by sep and config file values by commas. The default is always set and
+// lists defaultValue.
+func listSources(sources []Source, cliItems func(raw string) []string, defaultValue []string, sep string, items *[]string) []Source {
+	if sep == "" {
+		sep = ","
+	}
+	listed := make([]Source, len(sources))
+	for i, src := range sources {
+		split := func(raw string) []string { return splitList(raw, sep) }
+		switch src.Kind {
+		case flagutil.SourceCLI:
+			split = cliItems
+		case flagutil.SourceFile:
+			// Config file arrays are joined with commas.
+			split = func(raw string) []string { return splitList(raw, ",") }
+		case flagutil.SourceDefault:
+			split = func(string) []string { return defaultValue }
+		}
+		lookup := src.lookup
+		src.lookup = func() (string, bool) {
+			raw, ok := lookup()
+			if !ok {
+				return "", false
+			}
+			*items = split(raw)
+			return raw, len(*items) > 0 || src.Kind == flagutil.SourceDefault
+		}
+		listed[i] = src
+	}
+	return listed
+}
+
+// splitList splits raw by sep, trimming items and dropping empty ones, like
+// env.GetStringSlice.
+func splitList(raw, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// resolveStringSlice picks the first non-empty list: the CLI values, the ENV
+// value split by sep (default ","), then defaultValue.
+func resolveStringSlice(cli Source, cliItems func(raw string) []string, envKey string, defaultValue []string, sep string, layers []ChainOption) []string {
+	var items []string
+	sources := listSources(chain(cli, FromEnv(envKey, false), strings.Join(defaultValue, sep), layers...), cliItems, defaultValue, sep, &items)
+	value, _ := Resolve(sources, func(string) ([]string, error) { return items, nil })
+	return value
+}
+
+func parseInt(s string) (int, error) { return strconv.Atoi(s) }
+
+func parseInt64(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) }
@@ -0,0 +1,192 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+type pinnedCase struct {
+	name string
+	args []string
+	env  *string
+}
+
+func envValue(s string) *string { return &s }
+
+// pinnedCases cover the corner cases where the Resolve* functions used to
+// differ: empty, blank, zero, unparseable and rejected values per source.
+var pinnedCases = []pinnedCase{
+	{name: "nothing set"},
+	{name: "CLI", args: []string{"-v", "9"}},
+	{name: "CLI empty", args: []string{"-v", ""}},
+	{name: "CLI blank", args: []string{"-v", " "}},
+	{name: "CLI invalid", args: []string{"-v", "x"}},
+	{name: "CLI negative", args: []string{"-v", "-1"}},
+	{name: "CLI invalid ENV valid", args: []string{"-v", "x"}, env: envValue("5")},
+	{name: "ENV", env: envValue("5")},
+	{name: "ENV padded", env: envValue(" 5 ")},
+	{name: "ENV empty", env: envValue("")},
+	{name: "ENV blank", env: envValue("  ")},
+	{name: "ENV zero", env: envValue("0")},
+	{name: "ENV invalid", env: envValue("x")},
+	{name: "ENV negative", env: envValue("-1")},
+	{name: "ENV bool", env: envValue("false")},
+	{name: "ENV duration", env: envValue("2s")},
+}
+
+type pinnedResolver struct {
+	name string
+	fn   func(fs *flag.FlagSet) string
+}
+
+func pinnedResolvers() []pinnedResolver {
+	digits := func(s string) bool {
+		_, err := strconv.Atoi(s)
+		return err == nil
+	}
+	digitsErr := func(s string) error {
+		if !digits(s) {
+			return errors.New("not a number")
+		}
+		return nil
+	}
+	positive := func(n int) error {
+		if n <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	}
+	result := func(v any, err error) string {
+		if err != nil {
+			return fmt.Sprintf("%v!%v", v, err)
+		}
+		return fmt.Sprint(v)
+	}
+	return []pinnedResolver{
+		{"String", func(fs *flag.FlagSet) string { return ResolveString(fs, "v", "PIN_V", "d", true) }},
+		{"StringUntrimmed", func(fs *flag.FlagSet) string { return ResolveString(fs, "v", "PIN_V", "d", false) }},
+		{"StringNonEmpty", func(fs *flag.FlagSet) string { return ResolveStringNonEmpty(fs, "v", "PIN_V", "d", true) }},
+		{"StringWithValidator", func(fs *flag.FlagSet) string {
+			return ResolveStringWithValidator(fs, "v", "PIN_V", "d", true, digits)
+		}},
+		{"StringWithValidation", func(fs *flag.FlagSet) string {
+			return result(ResolveStringWithValidation(fs, "v", "PIN_V", "d", true, digitsErr))
+		}},
+		{"Int", func(fs *flag.FlagSet) string { return fmt.Sprint(ResolveInt(fs, "v", "PIN_V", 7, false)) }},
+		{"IntAllowZero", func(fs *flag.FlagSet) string { return fmt.Sprint(ResolveInt(fs, "v", "PIN_V", 7, true)) }},
+		{"Int64", func(fs *flag.FlagSet) string { return fmt.Sprint(ResolveInt64(fs, "v", "PIN_V", 7, false)) }},
+		{"IntWithValidation", func(fs *flag.FlagSet) string {
+			return result(ResolveIntWithValidation(fs, "v", "PIN_V", 7, false, positive))
+		}},
+		{"IntWithValidationBadDefault", func(fs *flag.FlagSet) string {
+			return result(ResolveIntWithValidation(fs, "v", "PIN_V", 0, true, positive))
+		}},
+		{"Int64WithValidation", func(fs *flag.FlagSet) string {
+			return result(ResolveInt64WithValidation(fs, "v", "PIN_V", 7, false, func(n int64) error { return positive(int(n)) }))
+		}},
+		{"Bool", func(fs *flag.FlagSet) string { return fmt.Sprint(ResolveBool(fs, "v", "PIN_V", true)) }},
+		{"Duration", func(fs *flag.FlagSet) string { return fmt.Sprint(ResolveDuration(fs, "v", "PIN_V", time.Second)) }},
+		{"Port", func(fs *flag.FlagSet) string { return result(ResolvePort(fs, "v", "PIN_V", 8080)) }},
+	}
+}
+
+// TestResolveWrappers_Pinned pins what the Resolve* wrappers return for every
+// pinned case, in pinnedCases order. A value followed by "!" is returned with
+// that error.
+func TestResolveWrappers_Pinned(t *testing.T) {
+	want := map[string][]string{
+		"String":                      {"d", "9", "", " ", "x", "-1", "x", "5", "5", "d", "d", "0", "x", "-1", "false", "2s"},
+		"StringUntrimmed":             {"d", "9", "", " ", "x", "-1", "x", "5", " 5 ", "d", "  ", "0", "x", "-1", "false", "2s"},
+		"StringNonEmpty":              {"d", "9", "d", "d", "x", "-1", "x", "5", "5", "d", "d", "0", "x", "-1", "false", "2s"},
+		"StringWithValidator":         {"d", "9", "d", "d", "d", "-1", "d", "5", "5", "d", "d", "0", "d", "-1", "d", "d"},
+		"StringWithValidation":        {"d!not a number", "9", "d!not a number", "d!not a number", "d!not a number", "-1", "5", "5", "5", "d!not a number", "d!not a number", "0", "d!not a number", "-1", "d!not a number", "d!not a number"},
+		"Int":                         {"7", "9", "7", "7", "7", "-1", "7", "5", "7", "7", "7", "7", "7", "-1", "7", "7"},
+		"IntAllowZero":                {"7", "9", "7", "7", "7", "-1", "7", "5", "7", "7", "7", "0", "7", "-1", "7", "7"},
+		"Int64":                       {"7", "9", "7", "7", "7", "-1", "7", "5", "7", "7", "7", "7", "7", "-1", "7", "7"},
+		"IntWithValidation":           {"7", "9", "7", "7", "7", "7", "7", "5", "7", "7", "7", "7", "7", "7", "7", "7"},
+		"IntWithValidationBadDefault": {"0!must be positive", "9", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive", "5", "5", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive", "0!must be positive"},
+		"Int64WithValidation":         {"7", "9", "7", "7", "7", "7", "7", "5", "7", "7", "7", "7", "7", "7", "7", "7"},
+		"Bool":                        {"true", "true", "true", "true", "true", "true", "true", "true", "true", "true", "true", "false", "true", "true", "false", "true"},
+		"Duration":                    {"1s", "1s", "1s", "1s", "1s", "1s", "1s", "1s", "1s", "1s", "1s", "0s", "1s", "1s", "1s", "2s"},
+		"Port":                        {"8080", "9", "8080", "8080", "8080", "8080", "8080", "5", "8080", "8080", "8080", "8080", "8080", "8080", "8080", "8080"},
+	}
+	for _, r := range pinnedResolvers() {
+		for i, c := range pinnedCases {
+			t.Run(r.name+"/"+c.name, func(t *testing.T) {
+				unsetEnv(t, "PIN_V")
+				if c.env != nil {
+					t.Setenv("PIN_V", *c.env)
+				}
+				fs := flag.NewFlagSet("pin", flag.ContinueOnError)
+				fs.String("v", "", "")
+				if err := fs.Parse(c.args); err != nil {
+					t.Fatal(err)
+				}
+				if got := r.fn(fs); got != want[r.name][i] {
+					t.Errorf("got %q, want %q", got, want[r.name][i])
+				}
+			})
+		}
+	}
+}
+
+func TestResolve_FallbackPolicy(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "port")
+	if err := fs.Parse([]string{"--port", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PORT", "9090")
+	sources := []Source{FromFlag(fs, "port"), FromEnv("TEST_PORT", true), FromDefault("8080")}
+
+	tests := []struct {
+		policy  FallbackPolicy
+		want    int
+		wantErr string
+	}{
+		{FallbackNext, 9090, ""},
+		{FallbackDefault, 8080, ""},
+		{FallbackError, 0, `invalid value "abc" for flag --port: strconv.Atoi: parsing "abc": invalid syntax`},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(sources, strconv.Atoi, WithFallback(tt.policy))
+		if got != tt.want || errString(err) != tt.wantErr {
+			t.Errorf("policy %d: Resolve() = %d, %v, want %d, %q", tt.policy, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	_, err := Resolve(sources, strconv.Atoi, WithFallback(FallbackError))
+	var ve *ValueError
+	if !errors.As(err, &ve) || ve.Kind != flagutil.SourceCLI || ve.Key != "port" || ve.Raw != "abc" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Resolve() error = %#v, want a *ValueError for the flag", err)
+	}
+}
+
+func TestResolve_Exhausted(t *testing.T) {
+	unsetEnv(t, "TEST_PORT")
+	if _, err := Resolve([]Source{FromEnv("TEST_PORT", true)}, strconv.Atoi); !errors.Is(err, ErrNoValue) {
+		t.Errorf("Resolve() with no value = %v, want ErrNoValue", err)
+	}
+
+	t.Setenv("TEST_PORT", "x")
+	_, err := Resolve([]Source{FromEnv("TEST_PORT", true), FromDefault("y")}, strconv.Atoi)
+	if want := `invalid default value "y"`; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Resolve() = %v, want the default's error %q", err, want)
+	}
+
+	t.Setenv("TEST_PORT", "0")
+	nonZero := FromEnv("TEST_PORT", true).SkipIf(func(raw string) bool { return raw == "0" })
+	if got, err := Resolve([]Source{nonZero, FromDefault("8080")}, strconv.Atoi); err != nil || got != 8080 {
+		t.Errorf("Resolve() with skipped zero = %d, %v, want 8080", got, err)
+	}
+	if _, ok := FromEnv("", true).Lookup(); ok {
+		t.Error("FromEnv(\"\") should never be set")
+	}
+}
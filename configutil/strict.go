@@ -0,0 +1,122 @@
+package configutil
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/soulteary/cli-kit/flagutil"
+)
+
+// ErrZeroNotAllowed is the cause of the error a strict resolution returns for
+// a zero environment value when allowZero is false.
+var ErrZeroNotAllowed = fmt.Errorf("zero is not allowed")
+
+var strictMode atomic.Bool
+
+// SetStrict turns strict mode on or off for every Resolve* function that
+// returns an error, and returns the previous setting. In strict mode a value
+// that is set but cannot be parsed or fails validation is returned as a
+// *ValueError naming its source and raw value, instead of being read as the
+// default or skipped for the next source, and a zero environment value is
+// reported (ErrZeroNotAllowed) rather than treated as unset when allowZero is
+// false.
+//
+// Functions without an error result (ResolveInt, ResolveBool, ...) cannot
+// report; use their *Strict variants, which are strict on every call. In
+// lenient mode those functions send each value they ignore to a warning
+// handler set with SetWarningHandler. ResolveStringSlice and its variants accept
+// every value, so strict mode does not change them.
+func SetStrict(strict bool) bool {
+	return strictMode.Swap(strict)
+}
+
+func isStrict() bool { return strictMode.Load() }
+
+// strictPolicy is the FallbackPolicy of the error-returning wrappers.
+func strictPolicy() FallbackPolicy {
+	if isStrict() {
+		return FallbackError
+	}
+	return FallbackNext
+}
+
+// strictError is what the error-returning wrappers report: the whole
+// *ValueError in strict mode, and the parse or validation error alone, as
+// they always have, otherwise.
+func strictError(err error) error {
+	if isStrict() && !errors.Is(err, ErrNoValue) {
+		return err
+	}
+	return cause(err)
+}
+
+// checkZero reports a zero environment value that would otherwise be
+// treated as unset, unless a CLI value takes precedence.
+func checkZero[T int | int64](cli, envSource Source, parse func(string) (T, error)) error {
+	if _, ok := cli.Lookup(); ok {
+		return nil
+	}
+	raw, ok := envSource.Lookup()
+	if !ok {
+		return nil
+	}
+	if n, err := parse(raw); err == nil && n == 0 {
+		return &ValueError{Kind: envSource.Kind, Key: envSource.Key, Raw: raw, Err: ErrZeroNotAllowed}
+	}
+	return nil
+}
+
+// resolveStrict resolves with every rejected value returned as an error.
+func resolveStrict[T any](sources []Source, defaultValue T, parse func(string) (T, error)) (T, error) {
+	value, err := Resolve(sources, parse, WithFallback(FallbackError))
+	if err != nil {
+		return defaultValue, err
+	}
+	return value, nil
+}
+
+// resolveIntStrict is the strict form of resolveInt.
+func resolveIntStrict[T int | int64](cli Source, envKey string, defaultValue T, allowZero bool, parse func(string) (T, error), layers []ChainOption) (T, error) {
+	envSource := FromEnv(envKey, false)
+	if !allowZero {
+		if err := checkZero(cli, envSource, parse); err != nil {
+			return defaultValue, err
+		}
+	}
+	return resolveStrict(chain(cli, envSource, fmt.Sprint(defaultValue), layers...), defaultValue, parse)
+}
+
+// warnIgnored sends the values a lenient resolution ignored to a warning
+// handler set with SetWarningHandler, redacted when they are secret.
+func warnIgnored(rejected []*ValueError, secret bool) {
+	for _, r := range rejected {
+		msg := r.Error()
+		if secret {
+			msg = r.redacted()
+		}
+		lenientf("ignored %s", msg)
+	}
+}
+
+// isSecret reports whether any source reads a secret.
+func isSecret(sources []Source) bool {
+	for _, src := range sources {
+		if src.secret {
+			return true
+		}
+	}
+	return false
+}
+
+// warnIfSubstituted warns when the winning value of a lenient resolution
+// could not be parsed and was read as the default.
+func warnIfSubstituted[T any](r Resolved[T], sources []Source, parse func(string) (T, error)) {
+	if r.Source == flagutil.SourceDefault {
+		return
+	}
+	if _, err := parse(r.Raw); err != nil {
+		src := &ValueError{Kind: r.Source, Key: r.Key, Raw: r.Raw, Err: err}
+		warnIgnored([]*ValueError{src}, isSecret(sources))
+	}
+}
@@ -0,0 +1,130 @@
+package configutil
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestResolveStrict(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("retries", "", "retries")
+	if err := fs.Parse([]string{"-retries", "many"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_TIMEOUT", "5")
+	t.Setenv("TEST_PORT", "0")
+	t.Setenv("TEST_DEBUG", "yes")
+
+	d, err := ResolveDurationStrict(fs, "timeout", "TEST_TIMEOUT", time.Second)
+	if d != time.Second || err == nil || !strings.HasPrefix(err.Error(), `invalid value "5" for env TEST_TIMEOUT: `) {
+		t.Errorf("ResolveDurationStrict() = %v, %v, want the default and an error naming TEST_TIMEOUT", d, err)
+	}
+	var ve *ValueError
+	if !errors.As(err, &ve) || ve.Raw != "5" {
+		t.Errorf("error = %#v, want a *ValueError with the raw value", err)
+	}
+
+	n, err := ResolveIntStrict(fs, "port", "TEST_PORT", 8080, false)
+	if n != 8080 || !errors.Is(err, ErrZeroNotAllowed) {
+		t.Errorf("ResolveIntStrict() = %d, %v, want ErrZeroNotAllowed", n, err)
+	}
+	if n, err := ResolveIntStrict(fs, "port", "TEST_PORT", 8080, true); n != 0 || err != nil {
+		t.Errorf("ResolveIntStrict(allowZero) = %d, %v, want 0", n, err)
+	}
+	if n, err := ResolveInt64Strict(fs, "port", "TEST_UNSET", 8080, false); n != 8080 || err != nil {
+		t.Errorf("ResolveInt64Strict() = %d, %v, want the default for an unset env", n, err)
+	}
+	if _, err := ResolveIntStrict(fs, "retries", "TEST_RETRIES", 3, false); err == nil || !strings.Contains(err.Error(), "flag --retries") {
+		t.Errorf("ResolveIntStrict() error = %v, want the CLI value reported", err)
+	}
+	if b, err := ResolveBoolStrict(fs, "debug", "TEST_DEBUG", true); !b || err == nil {
+		t.Errorf("ResolveBoolStrict() = %v, %v, want the default and an error", b, err)
+	}
+
+	pfs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	pfs.Int("port", 0, "port")
+	if err := pfs.Parse([]string{"--port", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ResolveIntStrictPflag(pfs, "port", "TEST_PORT", 8080, false); n != 0 || err != nil {
+		t.Errorf("ResolveIntStrictPflag() = %d, %v, want the CLI zero", n, err)
+	}
+	if _, err := ResolveDurationStrictPflag(pfs, "timeout", "TEST_TIMEOUT", time.Second); err == nil {
+		t.Error("ResolveDurationStrictPflag() should fail")
+	}
+}
+
+func TestSetStrict(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	t.Setenv("TEST_PORT", "abc")
+	t.Setenv("TEST_MODE", "slow")
+	t.Setenv("TEST_ZERO", "0")
+	t.Setenv("TEST_IP", "not-an-ip")
+
+	if port, err := ResolvePort(fs, "port", "TEST_PORT", 8080); port != 8080 || err != nil {
+		t.Errorf("lenient ResolvePort() = %d, %v, want the default", port, err)
+	}
+	if mode, err := ResolveEnum(fs, "mode", "TEST_MODE", "fast", []string{"fast", "safe"}, true); mode != "fast" || err != nil {
+		t.Errorf("lenient ResolveEnum() = %q, %v, want the default", mode, err)
+	}
+
+	previous := SetStrict(true)
+	defer SetStrict(previous)
+
+	if port, err := ResolvePort(fs, "port", "TEST_PORT", 8080); port != 8080 || err == nil || !strings.Contains(err.Error(), `"abc" for env TEST_PORT`) {
+		t.Errorf("strict ResolvePort() = %d, %v, want an error naming TEST_PORT", port, err)
+	}
+	if _, err := ResolvePort(fs, "port", "TEST_ZERO", 8080); !errors.Is(err, ErrZeroNotAllowed) {
+		t.Errorf("strict ResolvePort() error = %v, want ErrZeroNotAllowed", err)
+	}
+	if _, err := ResolveEnum(fs, "mode", "TEST_MODE", "fast", []string{"fast", "safe"}, true); err == nil || !strings.Contains(err.Error(), "env TEST_MODE") {
+		t.Errorf("strict ResolveEnum() error = %v, want an error naming TEST_MODE", err)
+	}
+	if _, err := ResolveIP(fs, "ip", "TEST_IP", "127.0.0.1"); err == nil || !strings.Contains(err.Error(), "env TEST_IP") {
+		t.Errorf("strict ResolveIP() error = %v, want an error naming TEST_IP", err)
+	}
+	if port, err := ResolvePort(fs, "port", "TEST_UNSET", 8080); port != 8080 || err != nil {
+		t.Errorf("strict ResolvePort() = %d, %v, want the default for an unset env", port, err)
+	}
+	t.Setenv("TEST_PEERS", "10.0.0.1,bad")
+	if _, err := ResolveIPSlice(fs, "peer", "TEST_PEERS", []string{"127.0.0.1"}, ","); err == nil || !strings.Contains(err.Error(), "env TEST_PEERS") {
+		t.Errorf("strict ResolveIPSlice() error = %v, want an error naming TEST_PEERS", err)
+	}
+	SetStrict(false)
+	if ips, err := ResolveIPSlice(fs, "peer", "TEST_PEERS", []string{"127.0.0.1"}, ","); err != nil || len(ips) != 1 || ips[0].String() != "127.0.0.1" {
+		t.Errorf("lenient ResolveIPSlice() = %v, %v, want the default", ips, err)
+	}
+}
+
+func TestLenientWarnings(t *testing.T) {
+	var warned []string
+	previous := SetWarningHandler(func(msg string) { warned = append(warned, msg) })
+	defer SetWarningHandler(previous)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	t.Setenv("TEST_TIMEOUT", "5")
+	t.Setenv("TEST_PORT", "abc")
+	t.Setenv("TEST_COUNT", "12")
+
+	if d := ResolveDuration(fs, "timeout", "TEST_TIMEOUT", time.Second); d != time.Second {
+		t.Errorf("ResolveDuration() = %v, want the default", d)
+	}
+	if port, _ := ResolvePort(fs, "port", "TEST_PORT", 8080); port != 8080 {
+		t.Errorf("ResolvePort() = %d, want the default", port)
+	}
+	if n := ResolveInt(fs, "count", "TEST_COUNT", 1, false); n != 12 {
+		t.Errorf("ResolveInt() = %d, want 12", n)
+	}
+	want := []string{
+		`ignored invalid value "5" for env TEST_TIMEOUT: time: missing unit in duration "5"`,
+		`ignored invalid value "abc" for env TEST_PORT: strconv.Atoi: parsing "abc": invalid syntax`,
+	}
+	if strings.Join(warned, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings = %q, want %q", warned, want)
+	}
+}
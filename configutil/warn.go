@@ -0,0 +1,63 @@
+package configutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// WarningHandler receives warnings about configuration that is accepted but
+// should be changed, such as the use of a legacy environment variable.
+type WarningHandler func(msg string)
+
+var warnings = struct {
+	sync.Mutex
+	handler WarningHandler
+}{handler: stderrWarning}
+
+// warningOutput is where the default handler writes; tests replace it.
+var warningOutput io.Writer = os.Stderr
+
+func stderrWarning(msg string) {
+	fmt.Fprintln(warningOutput, "warning: "+msg)
+}
+
+// SetWarningHandler replaces the handler for configutil warnings and returns
+// the previous one. The default writes deprecation warnings, such as a legacy
+// variable picked by EnvWithLegacy, as "warning: ..." lines to os.Stderr.
+// Values ignored by lenient resolvers are reported only to a handler set
+// here. nil discards every warning:
+//
+//	configutil.SetWarningHandler(func(msg string) { log.Printf("warning: %s", msg) })
+func SetWarningHandler(h WarningHandler) WarningHandler {
+	warnings.Lock()
+	defer warnings.Unlock()
+	previous := warnings.handler
+	warnings.handler = h
+	return previous
+}
+
+func currentWarningHandler() WarningHandler {
+	warnings.Lock()
+	defer warnings.Unlock()
+	return warnings.handler
+}
+
+// warnf reports a deprecation warning, to os.Stderr unless a handler is set.
+func warnf(format string, args ...any) {
+	if h := currentWarningHandler(); h != nil {
+		h(fmt.Sprintf(format, args...))
+	}
+}
+
+// lenientf reports a value a lenient resolver ignored. It is opt-in: nothing
+// is reported until a handler other than the default is set.
+func lenientf(format string, args ...any) {
+	h := currentWarningHandler()
+	if h == nil || reflect.ValueOf(h).Pointer() == reflect.ValueOf(stderrWarning).Pointer() {
+		return
+	}
+	h(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,108 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Alias defines alias as another name for the existing flag canonical on fs.
+// Both names share one value, HasFlag and GetFlagValue report the canonical
+// flag as set when the alias is used, and the alias is left out of generated
+// help, completion and BindEnv. fs.PrintDefaults, which flagutil cannot
+// filter, still lists it as "alias for --canonical"; set fs.Usage to
+// NewUsage(fs).Func() to keep it out of -h. It panics if canonical is not defined,
+// like flag does for other definition errors. Combine with Deprecate to
+// retire an old name.
+func Alias(fs *flag.FlagSet, alias, canonical string) {
+	f := fs.Lookup(canonical)
+	if f == nil {
+		panic(fmt.Sprintf("flagutil: alias %q for undefined flag %q", alias, canonical))
+	}
+	fs.Var(f.Value, alias, "alias for --"+canonical)
+	updateMeta(fs, func(m *setMeta) {
+		m.aliases[alias] = canonical
+		m.hidden[alias] = true
+	})
+}
+
+// Deprecate marks flag name on fs as deprecated. The first time it is set,
+// "Flag --name has been deprecated, message" is written to the FlagSet output,
+// matching pflag's MarkDeprecated. Deprecated flags still work but are left
+// out of generated help and completion, though fs.PrintDefaults still lists
+// them. It panics if name is not defined.
+func Deprecate(fs *flag.FlagSet, name, message string) {
+	f := fs.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("flagutil: deprecate undefined flag %q", name))
+	}
+	f.Value = &deprecatedValue{Value: f.Value, name: name, message: message, out: fs.Output}
+	updateMeta(fs, func(m *setMeta) { m.deprecated[name] = message })
+}
+
+// deprecatedValue wraps a flag value to warn once when it is set.
+type deprecatedValue struct {
+	flag.Value
+	name    string
+	message string
+	out     func() io.Writer
+	once    sync.Once
+}
+
+func (d *deprecatedValue) Set(s string) error {
+	d.once.Do(func() {
+		fmt.Fprintf(d.out(), "Flag --%s has been deprecated, %s\n", d.name, d.message)
+	})
+	return d.Value.Set(s)
+}
+
+// String is safe on the zero value flag.PrintDefaults creates to find out
+// whether a default is worth printing.
+func (d *deprecatedValue) String() string {
+	if d == nil || d.Value == nil {
+		return ""
+	}
+	return d.Value.String()
+}
+
+// IsBoolFlag keeps boolean flags usable without a value.
+func (d *deprecatedValue) IsBoolFlag() bool {
+	return isBoolValue(d.Value)
+}
+
+// Get implements flag.Getter when the wrapped value does.
+func (d *deprecatedValue) Get() any {
+	if g, ok := d.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return d.Value.String()
+}
+
+// flagNames returns name's canonical flag followed by all of its aliases
+// and its --no-name flag, if any.
+func flagNames(fs *flag.FlagSet, name string) []string {
+	names := []string{name}
+	readMeta(fs, func(m *setMeta) {
+		if canonical, ok := m.aliases[name]; ok {
+			name = canonical
+			names[0] = canonical
+		}
+		for alias, canonical := range m.aliases {
+			if canonical == name {
+				names = append(names, alias)
+			}
+		}
+		if _, ok := m.negations[negatedName(name)]; ok {
+			names = append(names, negatedName(name))
+		}
+	})
+	return names
+}
+
+// isAlias reports whether name was defined with Alias.
+func isAlias(key any, name string) bool {
+	var ok bool
+	readMeta(key, func(m *setMeta) { _, ok = m.aliases[name] })
+	return ok
+}
@@ -0,0 +1,105 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("listen-addr", ":8080", "Listen address")
+	fs.Bool("verbose", false, "Verbose output")
+	Alias(fs, "addr", "listen-addr")
+
+	if err := fs.Parse([]string{"-addr", ":9090"}); err != nil {
+		t.Fatal(err)
+	}
+	if !HasFlag(fs, "listen-addr") || !HasFlag(fs, "addr") {
+		t.Error("HasFlag() should report both names as set through the alias")
+	}
+	if HasFlag(fs, "verbose") {
+		t.Error("HasFlag() should not report unrelated flags")
+	}
+	if v, ok := GetFlagValue(fs, "listen-addr"); !ok || v != ":9090" {
+		t.Errorf("GetFlagValue(listen-addr) = %q, %v, want :9090", v, ok)
+	}
+
+	var help bytes.Buffer
+	if err := NewUsage(fs).WriteText(&help); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(help.String(), "--addr") {
+		t.Errorf("WriteText() should hide aliases:\n%s", help.String())
+	}
+	if b := BindEnv(fs, "APP"); b.EnvKey("addr") != "" || b.EnvKey("listen-addr") != "APP_LISTEN_ADDR" {
+		t.Errorf("BindEnv() keys: addr=%q listen-addr=%q", b.EnvKey("addr"), b.EnvKey("listen-addr"))
+	}
+}
+
+func TestAlias_UndefinedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Alias() should panic for an undefined canonical flag")
+		}
+	}()
+	Alias(flag.NewFlagSet("app", flag.ContinueOnError), "old", "missing")
+}
+
+func TestDeprecate(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.String("addr", "", "Listen address")
+	fs.Bool("debug", false, "Debug mode")
+	fs.String("listen", "", "Listen address")
+	Alias(fs, "bind", "listen")
+	Deprecate(fs, "addr", "use --listen instead")
+	Deprecate(fs, "debug", "use --log-level=debug instead")
+	Deprecate(fs, "bind", "use --listen instead")
+
+	if err := fs.Parse([]string{"-addr", "a", "--addr=b", "-debug", "-bind", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "Flag --addr has been deprecated, use --listen instead\n" +
+		"Flag --debug has been deprecated, use --log-level=debug instead\n" +
+		"Flag --bind has been deprecated, use --listen instead\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if GetString(fs, "addr", "") != "b" || !GetBool(fs, "debug", false) || GetString(fs, "listen", "") != "c" {
+		t.Error("deprecated flags should still set their values")
+	}
+	if !HasFlag(fs, "listen") {
+		t.Error("HasFlag(listen) should be true when set through a deprecated alias")
+	}
+
+	var help bytes.Buffer
+	if err := NewUsage(fs).WriteText(&help); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(help.String(), "--addr") || strings.Contains(help.String(), "--debug") {
+		t.Errorf("WriteText() should hide deprecated flags:\n%s", help.String())
+	}
+	if getter, ok := fs.Lookup("debug").Value.(flag.Getter); !ok || getter.Get() != true {
+		t.Error("deprecated value should keep flag.Getter")
+	}
+}
+
+func TestDeprecate_PrintDefaults(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.String("addr", ":8080", "Listen address")
+	Deprecate(fs, "addr", "use --listen instead")
+
+	fs.PrintDefaults()
+	if s := out.String(); !strings.Contains(s, "(default :8080)") || strings.Contains(s, "panic") {
+		t.Errorf("PrintDefaults() =\n%s", s)
+	}
+	var zero *deprecatedValue
+	if zero.String() != "" || (&deprecatedValue{}).String() != "" {
+		t.Error("String() should be empty for a zero deprecatedValue")
+	}
+}
@@ -0,0 +1,218 @@
+package flagutil
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ArgKind classifies a single command-line argument.
+type ArgKind int
+
+const (
+	// ArgFlag is a flag such as -v, --name or --name=value.
+	ArgFlag ArgKind = iota + 1
+	// ArgValue is the separate value of the preceding flag (--name value).
+	ArgValue
+	// ArgPositional is a non-flag argument before any "--" terminator.
+	ArgPositional
+	// ArgTerminator is the "--" argument that ends flag parsing.
+	ArgTerminator
+	// ArgAfterTerminator is any argument following "--".
+	ArgAfterTerminator
+)
+
+// String returns a readable name for the kind.
+func (k ArgKind) String() string {
+	switch k {
+	case ArgFlag:
+		return "flag"
+	case ArgValue:
+		return "value"
+	case ArgPositional:
+		return "positional"
+	case ArgTerminator:
+		return "terminator"
+	case ArgAfterTerminator:
+		return "after-terminator"
+	default:
+		return "unknown"
+	}
+}
+
+// ArgToken is one classified element of argv.
+type ArgToken struct {
+	// Index is the position of the element in the tokenized slice.
+	Index int
+	// Raw is the element as it appeared in argv.
+	Raw string
+	// Kind classifies the element.
+	Kind ArgKind
+	// Names lists the flags named by an ArgFlag element, using long names when the
+	// spec knows them. Bundled shorthands (-vp) yield several names.
+	Names []string
+	// Value is the inline value of an ArgFlag element (--name=value, -p80).
+	Value string
+	// HasValue reports whether Value was given inline.
+	HasValue bool
+}
+
+// ArgSpec describes the flags a tokenizer should recognise.
+// A nil *ArgSpec treats every flag as unknown: flags never consume the next
+// argument, "-name" is a long flag and scanning continues past positionals.
+type ArgSpec struct {
+	// Flags maps each long flag name to whether it requires a separate value
+	// (false for boolean or optional-value flags).
+	Flags map[string]bool
+	// Shorthands maps single-letter shorthands to long flag names.
+	Shorthands map[string]string
+	// Bundling treats "-abc" as the shorthands a, b and c (pflag style).
+	// When false, "-abc" is the long flag "abc" (standard flag style).
+	Bundling bool
+	// Interspersed allows flags after positional arguments. When false,
+	// everything after the first positional is positional, as in the flag package.
+	Interspersed bool
+}
+
+// SpecFromFlagSet builds an ArgSpec matching how fs.Parse reads arguments,
+// including shorthands registered with Shorthands.
+func SpecFromFlagSet(fs *flag.FlagSet) *ArgSpec {
+	spec := &ArgSpec{Flags: make(map[string]bool), Shorthands: make(map[string]string)}
+	if fs == nil {
+		return spec
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		spec.Flags[f.Name] = !isBoolValue(f.Value)
+		if letter := shorthandOf(fs, f.Name); letter != "" {
+			spec.Shorthands[letter] = f.Name
+		}
+	})
+	return spec
+}
+
+// SpecFromPflag builds an ArgSpec matching how a pflag.FlagSet reads arguments.
+// Interspersed is always true because pflag does not expose the setting;
+// set it to false on the result for sets that call SetInterspersed(false).
+func SpecFromPflag(fs *pflag.FlagSet) *ArgSpec {
+	spec := &ArgSpec{
+		Flags:        make(map[string]bool),
+		Shorthands:   make(map[string]string),
+		Bundling:     true,
+		Interspersed: true,
+	}
+	if fs == nil {
+		return spec
+	}
+	fs.VisitAll(func(f *pflag.Flag) {
+		spec.Flags[f.Name] = f.NoOptDefVal == ""
+		if f.Shorthand != "" {
+			spec.Shorthands[f.Shorthand] = f.Name
+		}
+	})
+	return spec
+}
+
+// isBoolValue reports whether a flag.Value is a boolean flag (-name without a value).
+func isBoolValue(v flag.Value) bool {
+	bf, ok := v.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// canonical maps a shorthand to its long name; other names are returned unchanged.
+func (s *ArgSpec) canonical(name string) string {
+	if s == nil {
+		return name
+	}
+	if long, ok := s.Shorthands[name]; ok {
+		return long
+	}
+	return name
+}
+
+// takesValue reports whether the named flag consumes the following argument.
+func (s *ArgSpec) takesValue(name string) bool {
+	return s != nil && s.Flags[name]
+}
+
+// TokenizeArgs classifies each element of args as a flag, a flag value, a
+// positional argument, the "--" terminator or an argument after it.
+func TokenizeArgs(args []string, spec *ArgSpec) []ArgToken {
+	interspersed := spec == nil || spec.Interspersed
+	tokens := make([]ArgToken, 0, len(args))
+	afterTerminator, expectValue, stopped := false, false, false
+
+	for i, arg := range args {
+		tok := ArgToken{Index: i, Raw: arg}
+		switch {
+		case afterTerminator:
+			tok.Kind = ArgAfterTerminator
+		case expectValue:
+			tok.Kind = ArgValue
+			expectValue = false
+		case stopped:
+			tok.Kind = ArgPositional
+		case arg == "--":
+			tok.Kind = ArgTerminator
+			afterTerminator = true
+		case len(arg) < 2 || arg[0] != '-':
+			tok.Kind = ArgPositional
+			stopped = !interspersed
+		case strings.HasPrefix(arg, "--") || spec == nil || !spec.Bundling:
+			tok.Kind = ArgFlag
+			expectValue = tokenizeLong(&tok, strings.TrimLeft(arg[:2], "-")+arg[2:], spec)
+		default:
+			tok.Kind = ArgFlag
+			expectValue = tokenizeShorthands(&tok, arg[1:], spec)
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// tokenizeLong fills tok for --name[=value] or -name[=value] and reports
+// whether the next argument is the flag's value.
+func tokenizeLong(tok *ArgToken, body string, spec *ArgSpec) bool {
+	name, value, hasValue := strings.Cut(body, "=")
+	name = spec.canonical(name)
+	tok.Names = []string{name}
+	tok.Value, tok.HasValue = value, hasValue
+	return !hasValue && spec.takesValue(name)
+}
+
+// tokenizeShorthands fills tok for a bundle such as -vp80, -vp=80 or -vp and
+// reports whether the next argument is the last shorthand's value.
+func tokenizeShorthands(tok *ArgToken, letters string, spec *ArgSpec) bool {
+	for j := 0; j < len(letters); j++ {
+		name := spec.canonical(letters[j : j+1])
+		tok.Names = append(tok.Names, name)
+		rest := letters[j+1:]
+		if strings.HasPrefix(rest, "=") {
+			tok.Value, tok.HasValue = rest[1:], true
+			return false
+		}
+		if spec.takesValue(name) {
+			if rest != "" {
+				tok.Value, tok.HasValue = rest, true
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlagToken reports whether any flag token names name.
+func hasFlagToken(tokens []ArgToken, name string) bool {
+	for _, tok := range tokens {
+		if tok.Kind != ArgFlag {
+			continue
+		}
+		for _, n := range tok.Names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
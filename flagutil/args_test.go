@@ -0,0 +1,199 @@
+package flagutil
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func tokenKinds(tokens []ArgToken) []ArgKind {
+	kinds := make([]ArgKind, len(tokens))
+	for i, tok := range tokens {
+		kinds[i] = tok.Kind
+	}
+	return kinds
+}
+
+func TestTokenizeArgs_FlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "", "name")
+	fs.Int("port", 0, "port")
+	fs.Bool("verbose", false, "verbose")
+	spec := SpecFromFlagSet(fs)
+
+	args := []string{"--name", "--port", "-verbose", "-port=80", "file.txt", "--verbose", "--", "--port"}
+	tokens := TokenizeArgs(args, spec)
+	// The flag package stops at the first positional, so "--" after it is positional too.
+	want := []ArgKind{ArgFlag, ArgValue, ArgFlag, ArgFlag, ArgPositional, ArgPositional, ArgPositional, ArgPositional}
+	if got := tokenKinds(tokens); !reflect.DeepEqual(got, want) {
+		t.Fatalf("kinds = %v, want %v", got, want)
+	}
+	tokens2 := TokenizeArgs([]string{"-verbose", "--", "-port"}, spec)
+	if got := tokenKinds(tokens2); !reflect.DeepEqual(got, []ArgKind{ArgFlag, ArgTerminator, ArgAfterTerminator}) {
+		t.Errorf("kinds = %v, want flag, terminator, after-terminator", got)
+	}
+	if tokens[3].Names[0] != "port" || !tokens[3].HasValue || tokens[3].Value != "80" {
+		t.Errorf("token[3] = %+v, want port=80", tokens[3])
+	}
+	if tokens[5].Index != 5 || tokens[5].Raw != "--verbose" {
+		t.Errorf("token[5] = %+v", tokens[5])
+	}
+}
+
+func TestTokenizeArgs_Pflag(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringP("name", "n", "", "name")
+	fs.IntP("port", "p", 0, "port")
+	fs.BoolP("verbose", "v", false, "verbose")
+	fs.CountP("debug", "d", "debug")
+	spec := SpecFromPflag(fs)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantKinds []ArgKind
+		wantNames [][]string
+		wantValue string
+	}{
+		{"bundle with separate value", []string{"-vp", "80"}, []ArgKind{ArgFlag, ArgValue}, [][]string{{"verbose", "port"}, nil}, ""},
+		{"bundle with attached value", []string{"-vp80", "x"}, []ArgKind{ArgFlag, ArgPositional}, [][]string{{"verbose", "port"}, nil}, "80"},
+		{"shorthand equals", []string{"-p=80"}, []ArgKind{ArgFlag}, [][]string{{"port"}}, "80"},
+		{"repeated count", []string{"-ddd"}, []ArgKind{ArgFlag}, [][]string{{"debug", "debug", "debug"}}, ""},
+		{"interspersed", []string{"a", "-v", "b"}, []ArgKind{ArgPositional, ArgFlag, ArgPositional}, [][]string{nil, {"verbose"}, nil}, ""},
+		{"unknown shorthand", []string{"-x", "y"}, []ArgKind{ArgFlag, ArgPositional}, [][]string{{"x"}, nil}, ""},
+		{"lone dash", []string{"-"}, []ArgKind{ArgPositional}, [][]string{nil}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := TokenizeArgs(tt.args, spec)
+			if got := tokenKinds(tokens); !reflect.DeepEqual(got, tt.wantKinds) {
+				t.Fatalf("kinds = %v, want %v", got, tt.wantKinds)
+			}
+			for i, tok := range tokens {
+				if !reflect.DeepEqual(tok.Names, tt.wantNames[i]) {
+					t.Errorf("token[%d].Names = %v, want %v", i, tok.Names, tt.wantNames[i])
+				}
+			}
+			if tokens[0].Value != tt.wantValue {
+				t.Errorf("token[0].Value = %q, want %q", tokens[0].Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestTokenizeArgs_NilSpec(t *testing.T) {
+	tokens := TokenizeArgs([]string{"pos", "--a", "b", "-c=d", "--", "-e"}, nil)
+	want := []ArgKind{ArgPositional, ArgFlag, ArgPositional, ArgFlag, ArgTerminator, ArgAfterTerminator}
+	if got := tokenKinds(tokens); !reflect.DeepEqual(got, want) {
+		t.Fatalf("kinds = %v, want %v", got, want)
+	}
+	if tokens[3].Names[0] != "c" || tokens[3].Value != "d" {
+		t.Errorf("token[3] = %+v, want c=d", tokens[3])
+	}
+}
+
+func TestArgKind_String(t *testing.T) {
+	kinds := map[ArgKind]string{
+		ArgFlag:            "flag",
+		ArgValue:           "value",
+		ArgPositional:      "positional",
+		ArgTerminator:      "terminator",
+		ArgAfterTerminator: "after-terminator",
+		ArgKind(0):         "unknown",
+	}
+	for kind, want := range kinds {
+		if kind.String() != want {
+			t.Errorf("ArgKind(%d).String() = %q, want %q", kind, kind.String(), want)
+		}
+	}
+}
+
+func TestHasFlagInArgs_Terminator(t *testing.T) {
+	args := []string{"--name", "x", "--", "--port"}
+	if HasFlagInArgs(args, "port") {
+		t.Error("HasFlagInArgs() should ignore flags after --")
+	}
+	if !HasFlagInArgs(args, "name") {
+		t.Error("HasFlagInArgs() should detect flags before --")
+	}
+}
+
+func TestHasFlagInArgsWithSpec(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("name", "", "name")
+	fs.Int("port", 0, "port")
+	spec := SpecFromFlagSet(fs)
+
+	args := []string{"--name", "--port"}
+	if HasFlagInArgs(args, "port") != true {
+		t.Error("HasFlagInArgs() without spec cannot tell values apart and should report --port")
+	}
+	if HasFlagInArgsWithSpec(args, "port", spec) {
+		t.Error("HasFlagInArgsWithSpec() should treat --port as the value of --name")
+	}
+	if !HasFlagInArgsWithSpec(args, "name", spec) {
+		t.Error("HasFlagInArgsWithSpec() should detect --name")
+	}
+	if HasFlagInArgsWithSpec([]string{"file", "--port", "80"}, "port", spec) {
+		t.Error("HasFlagInArgsWithSpec() should stop at the first positional for flag sets")
+	}
+	if HasFlagInArgsWithSpec(args, "", spec) {
+		t.Error("HasFlagInArgsWithSpec() should return false for empty name")
+	}
+
+	pfs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	pfs.BoolP("verbose", "v", false, "verbose")
+	pfs.IntP("port", "p", 0, "port")
+	pspec := SpecFromPflag(pfs)
+	if !HasFlagInArgsWithSpec([]string{"-vp", "80"}, "port", pspec) {
+		t.Error("HasFlagInArgsWithSpec() should detect bundled shorthand -vp")
+	}
+	if !HasFlagInArgsWithSpec([]string{"file", "-p", "80"}, "p", pspec) {
+		t.Error("HasFlagInArgsWithSpec() should accept shorthand names and interspersed flags")
+	}
+	if HasFlagInArgsWithSpec([]string{"-p", "-v"}, "verbose", pspec) {
+		t.Error("HasFlagInArgsWithSpec() should treat -v as the value of -p")
+	}
+}
+
+func TestHasFlagInOSArgsWithSpec(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("name", "", "name")
+	fs.Bool("port", false, "port")
+	os.Args = []string{"app", "--name", "--port"}
+	if HasFlagInOSArgsWithSpec("port", SpecFromFlagSet(fs)) {
+		t.Error("HasFlagInOSArgsWithSpec() should treat --port as a value")
+	}
+	if !HasFlagInOSArgsWithSpec("name", SpecFromFlagSet(nil)) {
+		t.Error("HasFlagInOSArgsWithSpec() with empty spec should detect --name")
+	}
+	if len(SpecFromPflag(nil).Flags) != 0 {
+		t.Error("SpecFromPflag(nil) should return an empty spec")
+	}
+}
+
+func TestHasFlagInOSArgs_CommandLine(t *testing.T) {
+	originalArgs, originalCommandLine := os.Args, flag.CommandLine
+	defer func() { os.Args, flag.CommandLine = originalArgs, originalCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("app", flag.ContinueOnError)
+	flag.CommandLine.String("name", "", "name")
+	flag.CommandLine.Bool("port", false, "port")
+	os.Args = []string{"app", "--name", "--port"}
+	if HasFlagInOSArgs("port") {
+		t.Error("HasFlagInOSArgs() should use flag.CommandLine to treat --port as a value")
+	}
+	if !HasFlagInOSArgs("name") {
+		t.Error("HasFlagInOSArgs() should detect --name")
+	}
+	os.Args = []string{"app", "--verbose"}
+	if !HasFlagInOSArgs("verbose") {
+		t.Error("HasFlagInOSArgs() should fall back to HasFlagInArgs for undefined flags")
+	}
+}
@@ -0,0 +1,152 @@
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/soulteary/cli-kit/env"
+	"github.com/spf13/pflag"
+)
+
+// EnvBinding maps the flags of a FlagSet to environment variables.
+// Create it with BindEnv or BindEnvPflag after all flags are defined,
+// and call Apply after Parse.
+type EnvBinding struct {
+	fs     flagSet
+	prefix string
+	keys   map[string]string
+	usage  map[string]string
+}
+
+// BindEnv binds every flag defined on fs to an environment variable named
+// after the flag (see EnvName) and appends "[env: NAME]" to each usage string.
+// Aliases (see Alias) are not bound.
+func BindEnv(fs *flag.FlagSet, prefix string) *EnvBinding {
+	return bindEnv(stdFlagSet{fs}, prefix)
+}
+
+// BindEnvPflag is BindEnv for a pflag.FlagSet.
+func BindEnvPflag(fs *pflag.FlagSet, prefix string) *EnvBinding {
+	return bindEnv(pflagFlagSet{fs}, prefix)
+}
+
+func bindEnv(fs flagSet, prefix string) *EnvBinding {
+	b := &EnvBinding{
+		fs:     fs,
+		prefix: prefix,
+		keys:   make(map[string]string),
+		usage:  make(map[string]string),
+	}
+	for _, f := range fs.all() {
+		if isAlias(fs.key(), f.Name) || isNegation(fs.key(), f.Name) {
+			continue
+		}
+		// Binding again replaces the annotation of an earlier binding.
+		usage := f.Usage
+		if key := boundEnvKey(fs.key(), f.Name); key != "" {
+			usage = strings.TrimSuffix(usage, envUsageSuffix(key))
+		}
+		b.usage[f.Name] = usage
+		b.bind(f.Name, EnvName(prefix, f.Name))
+	}
+	return b
+}
+
+// EnvName derives an environment variable name from a prefix and a flag name:
+// letters are upper-cased and any other character becomes "_", so
+// EnvName("APP", "max-conns") is "APP_MAX_CONNS". An empty prefix yields "MAX_CONNS".
+func EnvName(prefix, flagName string) string {
+	name := envSegment(flagName)
+	prefix = strings.TrimRight(envSegment(prefix), "_")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+func envSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// Override binds flagName to envKey instead of the derived name.
+// An empty envKey removes the binding for that flag.
+func (b *EnvBinding) Override(flagName, envKey string) *EnvBinding {
+	if _, ok := b.usage[flagName]; ok {
+		b.bind(flagName, envKey)
+	}
+	return b
+}
+
+// EnvKey returns the environment variable bound to flagName, or "" if none.
+func (b *EnvBinding) EnvKey(flagName string) string {
+	return b.keys[flagName]
+}
+
+// bind records the binding and refreshes the flag's usage annotation.
+func (b *EnvBinding) bind(flagName, envKey string) {
+	usage := b.usage[flagName]
+	if envKey == "" {
+		delete(b.keys, flagName)
+	} else {
+		b.keys[flagName] = envKey
+		usage += envUsageSuffix(envKey)
+	}
+	b.fs.setUsage(flagName, usage)
+	updateMeta(b.fs.key(), func(m *setMeta) {
+		if envKey == "" {
+			delete(m.envKeys, flagName)
+		} else {
+			m.envKeys[flagName] = envKey
+		}
+	})
+}
+
+// Apply sets every flag that was not given on the command line from its bound
+// environment variable, giving CLI > ENV > default priority. Empty variables
+// are treated as unset. Invalid values are reported together in one error.
+//
+// Flags are set with Set, so afterwards HasFlag (and fs.Visit) report them as
+// set like command-line flags; use FlagSource to tell the two apart.
+func (b *EnvBinding) Apply() error {
+	var errs []error
+	for _, f := range b.fs.all() {
+		key, ok := b.keys[f.Name]
+		if !ok || b.fs.changed(f.Name) {
+			continue
+		}
+		value := env.Get(key, "")
+		if value == "" {
+			continue
+		}
+		err := withoutLiteralWarning(f.Value, func() error { return b.fs.set(f.Name, value) })
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s (flag %s): %w", value, key, f.Name, err))
+			continue
+		}
+		recordSource(b.fs.key(), f.Name, SourceEnv)
+	}
+	return errors.Join(errs...)
+}
+
+// envUsageSuffix is the annotation appended to a bound flag's usage string.
+func envUsageSuffix(envKey string) string {
+	return " [env: " + envKey + "]"
+}
+
+// boundEnvKey returns the environment variable bound to a flag, if any.
+func boundEnvKey(key any, flagName string) string {
+	var envKey string
+	readMeta(key, func(m *setMeta) { envKey = m.envKeys[flagName] })
+	return envKey
+}
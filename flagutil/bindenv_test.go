@@ -0,0 +1,199 @@
+package flagutil
+
+import (
+	"flag"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"weak"
+
+	"github.com/spf13/pflag"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		prefix, flag, want string
+	}{
+		{"APP", "max-conns", "APP_MAX_CONNS"},
+		{"app_", "max-conns", "APP_MAX_CONNS"},
+		{"", "db.host", "DB_HOST"},
+		{"my-app", "tls_cert", "MY_APP_TLS_CERT"},
+		{"APP", "v2", "APP_V2"},
+	}
+	for _, tt := range tests {
+		if got := EnvName(tt.prefix, tt.flag); got != tt.want {
+			t.Errorf("EnvName(%q, %q) = %q, want %q", tt.prefix, tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	t.Run("priority CLI > ENV > default", func(t *testing.T) {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		conns := fs.Int("max-conns", 10, "maximum connections")
+		host := fs.String("host", "localhost", "listen host")
+		timeout := fs.Duration("timeout", time.Second, "timeout")
+		b := BindEnv(fs, "APP")
+
+		t.Setenv("APP_MAX_CONNS", "50")
+		t.Setenv("APP_HOST", "0.0.0.0")
+		t.Setenv("APP_TIMEOUT", "")
+
+		if err := fs.Parse([]string{"--host", "127.0.0.1"}); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		if err := b.Apply(); err != nil {
+			t.Fatalf("Apply() failed: %v", err)
+		}
+		if *conns != 50 {
+			t.Errorf("max-conns = %d, want 50 from env", *conns)
+		}
+		if *host != "127.0.0.1" {
+			t.Errorf("host = %q, want CLI value", *host)
+		}
+		if *timeout != time.Second {
+			t.Errorf("timeout = %v, want default (empty env ignored)", *timeout)
+		}
+		if !HasFlag(fs, "max-conns") {
+			t.Error("HasFlag() should report env-applied flags as set")
+		}
+	})
+
+	t.Run("usage annotations and overrides", func(t *testing.T) {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.Int("max-conns", 10, "maximum connections")
+		fs.String("token", "", "API token")
+		fs.Bool("debug", false, "debug mode")
+		b := BindEnv(fs, "APP").
+			Override("token", "API_TOKEN").
+			Override("debug", "").
+			Override("missing", "X")
+
+		if got := fs.Lookup("max-conns").Usage; got != "maximum connections [env: APP_MAX_CONNS]" {
+			t.Errorf("max-conns usage = %q", got)
+		}
+		if got := fs.Lookup("token").Usage; got != "API token [env: API_TOKEN]" {
+			t.Errorf("token usage = %q", got)
+		}
+		if got := fs.Lookup("debug").Usage; got != "debug mode" {
+			t.Errorf("debug usage = %q, want no annotation", got)
+		}
+		if b.EnvKey("token") != "API_TOKEN" || b.EnvKey("debug") != "" || b.EnvKey("missing") != "" {
+			t.Errorf("EnvKey() = %q, %q, %q", b.EnvKey("token"), b.EnvKey("debug"), b.EnvKey("missing"))
+		}
+
+		t.Setenv("APP_TOKEN", "ignored")
+		t.Setenv("API_TOKEN", "secret")
+		t.Setenv("APP_DEBUG", "true")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		if err := b.Apply(); err != nil {
+			t.Fatalf("Apply() failed: %v", err)
+		}
+		if got := GetString(fs, "token", ""); got != "secret" {
+			t.Errorf("token = %q, want value of overridden key", got)
+		}
+		if HasFlag(fs, "debug") {
+			t.Error("debug should not be applied after its binding was removed")
+		}
+	})
+
+	t.Run("invalid values are reported together", func(t *testing.T) {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.Int("port", 80, "port")
+		fs.Duration("timeout", time.Second, "timeout")
+		b := BindEnv(fs, "")
+		t.Setenv("PORT", "eighty")
+		t.Setenv("TIMEOUT", "5")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		err := b.Apply()
+		if err == nil {
+			t.Fatal("Apply() should fail for invalid env values")
+		}
+		for _, want := range []string{`"eighty" for PORT (flag port)`, `"5" for TIMEOUT (flag timeout)`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Apply() error %q should contain %q", err, want)
+			}
+		}
+	})
+}
+
+func TestBindEnvPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.IntP("max-conns", "m", 10, "maximum connections")
+	fs.StringSlice("tags", nil, "tags")
+	fs.Bool("verbose", false, "verbose")
+	b := BindEnvPflag(fs, "APP")
+
+	if got := fs.Lookup("tags").Usage; got != "tags [env: APP_TAGS]" {
+		t.Errorf("tags usage = %q", got)
+	}
+	t.Setenv("APP_MAX_CONNS", "20")
+	t.Setenv("APP_TAGS", "a,b")
+	t.Setenv("APP_VERBOSE", "true")
+	if err := fs.Parse([]string{"-m", "5"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if err := b.Apply(); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if got := GetIntPflag(fs, "max-conns", 0); got != 5 {
+		t.Errorf("max-conns = %d, want CLI value 5", got)
+	}
+	if got := GetStringSlicePflag(fs, "tags", nil); strings.Join(got, ",") != "a,b" {
+		t.Errorf("tags = %v, want [a b]", got)
+	}
+	if !GetBoolPflag(fs, "verbose", false) {
+		t.Error("verbose should be applied from env")
+	}
+
+	t.Setenv("APP_MAX_CONNS", "many")
+	fs2 := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs2.Int("max-conns", 10, "maximum connections")
+	b2 := BindEnvPflag(fs2, "APP")
+	if err := fs2.Parse(nil); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if err := b2.Apply(); err == nil || !strings.Contains(err.Error(), "APP_MAX_CONNS") {
+		t.Errorf("Apply() error = %v, want error naming APP_MAX_CONNS", err)
+	}
+}
+
+func TestBindEnv_Twice(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 0, "listen port")
+	BindEnv(fs, "APP")
+	BindEnv(fs, "SVC")
+	if got := fs.Lookup("port").Usage; got != "listen port [env: SVC_PORT]" {
+		t.Errorf("usage after binding twice = %q", got)
+	}
+}
+
+func TestRegistry_ReleasesCollectedFlagSets(t *testing.T) {
+	wk := func() weak.Pointer[flag.FlagSet] {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.Int("port", 0, "listen port")
+		BindEnv(fs, "APP")
+		if _, ok := registry.sets[weak.Make(fs)]; !ok {
+			t.Fatal("BindEnv() recorded no metadata")
+		}
+		return weak.Make(fs)
+	}()
+	for range 20 {
+		runtime.GC()
+		registry.Lock()
+		_, ok := registry.sets[wk]
+		registry.Unlock()
+		if !ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("metadata of a collected FlagSet was not released")
+}
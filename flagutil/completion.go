@@ -0,0 +1,489 @@
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// CompleteCommand is the hidden first argument the generated scripts pass to
+// the program to request dynamic completions: "prog __complete <flag> <prefix>".
+// Programs answer it with HandleCompletion before parsing flags.
+const CompleteCommand = "__complete"
+
+// ErrUnsupportedShell is returned by GenerateCompletion for an unknown shell.
+var ErrUnsupportedShell = errors.New("unsupported shell")
+
+// CompletionFunc returns candidate values for a flag given the partial word
+// typed so far. Candidates need not be filtered by prefix.
+type CompletionFunc func(prefix string) []string
+
+type completionKind int
+
+const (
+	completeFile completionKind = iota + 1
+	completeDir
+	completeFunc
+)
+
+// completion describes how the value of a flag is completed.
+type completion struct {
+	kind completionKind
+	exts []string
+	fn   CompletionFunc
+}
+
+// MarkFile makes shells complete file paths for the value of flag name,
+// optionally limited to the given extensions (without the leading dot).
+func MarkFile(fs *flag.FlagSet, name string, exts ...string) {
+	setCompletion(fs, name, completion{kind: completeFile, exts: trimExts(exts)})
+}
+
+// MarkFilePflag is MarkFile for a pflag.FlagSet.
+func MarkFilePflag(fs *pflag.FlagSet, name string, exts ...string) {
+	setCompletion(fs, name, completion{kind: completeFile, exts: trimExts(exts)})
+}
+
+// MarkDir makes shells complete directory paths for the value of flag name.
+func MarkDir(fs *flag.FlagSet, name string) {
+	setCompletion(fs, name, completion{kind: completeDir})
+}
+
+// MarkDirPflag is MarkDir for a pflag.FlagSet.
+func MarkDirPflag(fs *pflag.FlagSet, name string) {
+	setCompletion(fs, name, completion{kind: completeDir})
+}
+
+// RegisterCompletion makes shells complete the value of flag name by running
+// the program with CompleteCommand, which HandleCompletion answers with fn.
+func RegisterCompletion(fs *flag.FlagSet, name string, fn CompletionFunc) {
+	setCompletion(fs, name, completion{kind: completeFunc, fn: fn})
+}
+
+// RegisterCompletionPflag is RegisterCompletion for a pflag.FlagSet.
+func RegisterCompletionPflag(fs *pflag.FlagSet, name string, fn CompletionFunc) {
+	setCompletion(fs, name, completion{kind: completeFunc, fn: fn})
+}
+
+func setCompletion(key any, name string, c completion) {
+	updateMeta(key, func(m *setMeta) { m.completions[name] = c })
+}
+
+func trimExts(exts []string) []string {
+	trimmed := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		if ext = strings.TrimPrefix(ext, "."); ext != "" {
+			trimmed = append(trimmed, ext)
+		}
+	}
+	return trimmed
+}
+
+// HandleCompletion answers a dynamic completion request. If args (usually
+// os.Args[1:]) start with CompleteCommand, it writes the candidates for the
+// named flag that start with the given prefix to w, one per line, and returns
+// true; the program should then exit. Otherwise it returns false.
+// Enum flags are answered with their allowed values.
+func HandleCompletion(fs *flag.FlagSet, args []string, w io.Writer) bool {
+	return handleCompletion(stdFlagSet{fs}, args, w)
+}
+
+// HandleCompletionPflag is HandleCompletion for a pflag.FlagSet.
+func HandleCompletionPflag(fs *pflag.FlagSet, args []string, w io.Writer) bool {
+	return handleCompletion(pflagFlagSet{fs}, args, w)
+}
+
+func handleCompletion(fs flagSet, args []string, w io.Writer) bool {
+	if len(args) == 0 || args[0] != CompleteCommand {
+		return false
+	}
+	var name, prefix string
+	if len(args) > 1 {
+		name = strings.TrimLeft(args[1], "-")
+	}
+	if len(args) > 2 {
+		prefix = args[2]
+	}
+	f := fs.lookup(name)
+	if f == nil {
+		return true
+	}
+	var candidates []string
+	if c := lookupCompletion(fs.key(), name); c.kind == completeFunc && c.fn != nil {
+		candidates = c.fn(prefix)
+	} else if ev, ok := f.Value.(EnumValuer); ok {
+		candidates = ev.AllowedValues()
+	}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			fmt.Fprintln(w, candidate)
+		}
+	}
+	return true
+}
+
+func lookupCompletion(key any, name string) completion {
+	var c completion
+	readMeta(key, func(m *setMeta) { c = m.completions[name] })
+	return c
+}
+
+// GenerateCompletion writes a completion script for fs to w. shell is one of
+// "bash", "zsh", "fish" or "powershell". The script completes flag names,
+// allowed values of Enum flags, paths for flags marked with MarkFile or MarkDir,
+// and values registered with RegisterCompletion through CompleteCommand.
+// The command name is the FlagSet name.
+func GenerateCompletion(fs *flag.FlagSet, shell string, w io.Writer) error {
+	return generateCompletion(stdFlagSet{fs}, shell, w)
+}
+
+// GenerateCompletionPflag is GenerateCompletion for a pflag.FlagSet.
+func GenerateCompletionPflag(fs *pflag.FlagSet, shell string, w io.Writer) error {
+	return generateCompletion(pflagFlagSet{fs}, shell, w)
+}
+
+// completionFlag is a visible flag prepared for script generation.
+type completionFlag struct {
+	name string
+	// short and long are the option forms offered to the user ("-p", "--port").
+	short, long string
+	// match lists every form accepted on the command line.
+	match      []string
+	usage      string
+	takesValue bool
+	allowed    []string
+	completion completion
+}
+
+// words returns the offered option forms.
+func (f completionFlag) words() []string {
+	var words []string
+	for _, w := range []string{f.short, f.long} {
+		if w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func generateCompletion(fs flagSet, shell string, w io.Writer) error {
+	var write func(io.Writer, string, []completionFlag) error
+	switch strings.ToLower(shell) {
+	case "bash":
+		write = writeBashCompletion
+	case "zsh":
+		write = writeZshCompletion
+	case "fish":
+		write = writeFishCompletion
+	case "powershell", "pwsh":
+		write = writePowerShellCompletion
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedShell, shell)
+	}
+	return write(w, fs.name(), completionFlags(fs))
+}
+
+func completionFlags(fs flagSet) []completionFlag {
+	_, isStd := fs.(stdFlagSet)
+	u := &Usage{fs: fs}
+	var flags []completionFlag
+	for _, group := range u.collect() {
+		for _, e := range group.entries {
+			f := completionFlag{
+				name:       e.name,
+				usage:      firstLine(e.usage),
+				takesValue: !e.isBool,
+				allowed:    e.allowed,
+				completion: lookupCompletion(fs.key(), e.name),
+			}
+			if isStd {
+				// The flag package accepts one or two dashes for every flag.
+				f.short, f.long = e.short, e.long
+				f.match = []string{"-" + e.name, "--" + e.name}
+			} else {
+				// Offer pflag's own spellings: one dash only for the
+				// shorthand, two for every long name, even one letter long.
+				f.long = "--" + e.name
+				if info := fs.lookup(e.name); info != nil && info.Shorthand != "" {
+					f.short = "-" + info.Shorthand
+				}
+				f.match = f.words()
+			}
+			flags = append(flags, f)
+		}
+	}
+	slices.SortFunc(flags, func(a, b completionFlag) int { return strings.Compare(a.name, b.name) })
+	return flags
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return strings.TrimSpace(s)
+}
+
+// completionIdent turns a command name into a shell function name fragment.
+func completionIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// shellQuote single-quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, which escapes quotes with a backslash.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// fishWord quotes s, unless it is plain, as one word of a fish argument list.
+func fishWord(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,/=+@%") == "" {
+		return s
+	}
+	return fishQuote(s)
+}
+
+// psQuote single-quotes s for PowerShell.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func writeBashCompletion(w io.Writer, cmd string, flags []completionFlag) error {
+	fn := "_" + completionIdent(cmd) + "_completions"
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", cmd)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev ext v\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, f := range flags {
+		if !f.takesValue {
+			continue
+		}
+		fmt.Fprintf(&b, "        %s)\n", strings.Join(f.match, "|"))
+		switch {
+		case f.completion.kind == completeFunc:
+			fmt.Fprintf(&b, "            COMPREPLY=( $(%s %s %s \"$cur\" 2>/dev/null) )\n",
+				shellQuote(cmd), CompleteCommand, shellQuote(f.name))
+		case f.completion.kind == completeDir:
+			b.WriteString("            COMPREPLY=( $(compgen -d -- \"$cur\") )\n")
+		case f.completion.kind == completeFile && len(f.completion.exts) > 0:
+			b.WriteString("            COMPREPLY=( $(compgen -d -- \"$cur\") )\n")
+			fmt.Fprintf(&b, "            for ext in %s; do\n", strings.Join(quoteAll(f.completion.exts, shellQuote), " "))
+			b.WriteString("                COMPREPLY+=( $(compgen -f -X \"!*.$ext\" -- \"$cur\") )\n")
+			b.WriteString("            done\n")
+		case f.completion.kind == completeFile:
+			b.WriteString("            COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+		case len(f.allowed) > 0:
+			// compgen -W would split and expand the values.
+			b.WriteString("            COMPREPLY=()\n")
+			fmt.Fprintf(&b, "            for v in %s; do\n", strings.Join(quoteAll(f.allowed, shellQuote), " "))
+			b.WriteString("                [[ \"$v\" == \"$cur\"* ]] && COMPREPLY+=( \"$v\" )\n")
+			b.WriteString("            done\n")
+		default:
+			b.WriteString("            COMPREPLY=()\n")
+		}
+		b.WriteString("            return\n            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	var words []string
+	for _, f := range flags {
+		words = append(words, f.words()...)
+	}
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", shellQuote(strings.Join(words, " ")))
+	b.WriteString("        return\n    fi\n")
+	b.WriteString("    COMPREPLY=( $(compgen -f -- \"$cur\") )\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o filenames -F %s %s\n", fn, shellQuote(cmd))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func quoteAll(values []string, quote func(string) string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return quoted
+}
+
+// zshValue escapes a value for the (a b c) list action of an _arguments
+// spec, which is itself single-quoted.
+func zshValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(" \t\\:()[]{}$\"'`;&|<>*?~#!", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ReplaceAll(b.String(), "'", `'\''`)
+}
+
+// zshDescription escapes a description for use inside an _arguments spec.
+func zshDescription(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
+
+func writeZshCompletion(w io.Writer, cmd string, flags []completionFlag) error {
+	fn := "_" + completionIdent(cmd)
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", cmd)
+	fmt.Fprintf(&b, "%s_dynamic() {\n", fn)
+	b.WriteString("    local -a values\n")
+	fmt.Fprintf(&b, "    values=(${(f)\"$(%s %s \"$1\" \"$PREFIX\" 2>/dev/null)\"})\n", shellQuote(cmd), CompleteCommand)
+	b.WriteString("    compadd -a values\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    _arguments -s")
+	for _, f := range flags {
+		var action string
+		if f.takesValue {
+			action = ":" + zshDescription(f.name) + ":"
+			switch {
+			case f.completion.kind == completeFunc:
+				action += "{" + fn + "_dynamic " + f.name + "}"
+			case f.completion.kind == completeDir:
+				action += "_files -/"
+			case f.completion.kind == completeFile && len(f.completion.exts) > 0:
+				action += `_files -g "*.(` + strings.Join(f.completion.exts, "|") + `)"`
+			case f.completion.kind == completeFile:
+				action += "_files"
+			case len(f.allowed) > 0:
+				action += "(" + strings.Join(quoteAll(f.allowed, zshValue), " ") + ")"
+			}
+		}
+		spec := "[" + zshDescription(f.usage) + "]" + action
+		words := f.words()
+		if len(words) == 1 {
+			fmt.Fprintf(&b, " \\\n        '%s%s'", words[0], spec)
+		} else {
+			fmt.Fprintf(&b, " \\\n        '(%s)'{%s}'%s'", strings.Join(words, " "), strings.Join(words, ","), spec)
+		}
+	}
+	b.WriteString(" \\\n        '*:file:_files'\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "if [ \"$funcstack[1]\" = %s ]; then\n", shellQuote(fn))
+	fmt.Fprintf(&b, "    %s \"$@\"\n", fn)
+	b.WriteString("else\n")
+	fmt.Fprintf(&b, "    compdef %s %s\n", fn, shellQuote(cmd))
+	b.WriteString("fi\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer, cmd string, flags []completionFlag) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", cmd)
+	for _, f := range flags {
+		line := "complete -c " + fishQuote(cmd)
+		if f.short != "" {
+			line += " -s " + fishQuote(strings.TrimPrefix(f.short, "-"))
+		}
+		if f.long != "" {
+			line += " -l " + fishQuote(strings.TrimPrefix(f.long, "--"))
+		}
+		if f.usage != "" {
+			line += " -d " + fishQuote(f.usage)
+		}
+		if f.takesValue {
+			switch {
+			case f.completion.kind == completeFunc:
+				line += " -x -a " + fishQuote("("+cmd+" "+CompleteCommand+" "+f.name+" (commandline -ct))")
+			case f.completion.kind == completeDir:
+				line += " -x -a '(__fish_complete_directories (commandline -ct))'"
+			case f.completion.kind == completeFile && len(f.completion.exts) > 0:
+				line += " -x -a " + fishQuote("(__fish_complete_suffix "+strings.Join(prefixAll(f.completion.exts, "."), " ")+")")
+			case f.completion.kind == completeFile:
+				line += " -r -F"
+			case len(f.allowed) > 0:
+				line += " -x -a " + fishQuote(strings.Join(quoteAll(f.allowed, fishWord), " "))
+			default:
+				line += " -x"
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func prefixAll(values []string, prefix string) []string {
+	prefixed := make([]string, len(values))
+	for i, v := range values {
+		prefixed[i] = prefix + v
+	}
+	return prefixed
+}
+
+// psPathCompletion lists matching paths as completion results and returns.
+func psPathCompletion(option, filter string) string {
+	return "Get-ChildItem" + option + " -Path \"$wordToComplete*\" -ErrorAction SilentlyContinue" + filter +
+		" | ForEach-Object { [System.Management.Automation.CompletionResult]::new($_.FullName, $_.Name, 'ProviderItem', $_.FullName) }; return"
+}
+
+func writePowerShellCompletion(w io.Writer, cmd string, flags []completionFlag) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# powershell completion for %s\n", cmd)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", psQuote(cmd))
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	b.WriteString("    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.StartOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n")
+	b.WriteString("    $prev = if ($wordToComplete) { $words[-2] } else { $words[-1] }\n")
+	b.WriteString("    $values = $null\n")
+	b.WriteString("    switch -CaseSensitive ($prev) {\n")
+	for _, f := range flags {
+		if !f.takesValue {
+			continue
+		}
+		var body string
+		switch {
+		case f.completion.kind == completeFunc:
+			body = fmt.Sprintf("$values = @(& %s %s %s $wordToComplete 2>$null)", psQuote(cmd), CompleteCommand, psQuote(f.name))
+		case f.completion.kind == completeDir:
+			body = psPathCompletion(" -Directory", "")
+		case f.completion.kind == completeFile && len(f.completion.exts) > 0:
+			body = psPathCompletion("", fmt.Sprintf(" | Where-Object { $_.PSIsContainer -or @(%s) -contains $_.Extension }",
+				strings.Join(quoteAll(prefixAll(f.completion.exts, "."), psQuote), ", ")))
+		case f.completion.kind == completeFile:
+			// Returning nothing falls back to PowerShell's path completion.
+			body = "return"
+		case len(f.allowed) > 0:
+			body = "$values = @(" + strings.Join(quoteAll(f.allowed, psQuote), ", ") + ")"
+		default:
+			body = "$values = @()"
+		}
+		fmt.Fprintf(&b, "        {$_ -in @(%s)} { %s }\n", strings.Join(quoteAll(f.match, psQuote), ", "), body)
+	}
+	b.WriteString("    }\n")
+	b.WriteString("    if ($null -eq $values) {\n")
+	b.WriteString("        if (-not $wordToComplete.StartsWith('-')) { return }\n")
+	b.WriteString("        $values = @(\n")
+	for _, f := range flags {
+		for _, word := range f.words() {
+			fmt.Fprintf(&b, "            %s\n", psQuote(word))
+		}
+	}
+	b.WriteString("        )\n")
+	b.WriteString("    }\n")
+	b.WriteString("    $values | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
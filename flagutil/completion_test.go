@@ -0,0 +1,239 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newCompletionTestFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Bool("v", false, "Verbose output")
+	fs.String("config", "", "Config `file`")
+	fs.String("data", "", "Data directory")
+	fs.String("region", "", "Cloud region")
+	fs.String("internal", "", "Internal knob")
+	Enum(fs, "log-level", "info", []string{"debug", "info"}, false, "Log level")
+	MarkFile(fs, "config", ".yaml", "json")
+	MarkDir(fs, "data")
+	RegisterCompletion(fs, "region", func(prefix string) []string {
+		return []string{"eu-west", "us-east", "us-west"}
+	})
+	Hide(fs, "internal")
+	return fs
+}
+
+func TestGenerateCompletion(t *testing.T) {
+	fs := newCompletionTestFlagSet()
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			"complete -o filenames -F _app_completions 'app'\n",
+			"        -log-level|--log-level)\n            COMPREPLY=()\n            for v in 'debug' 'info'; do\n",
+			"        -data|--data)\n            COMPREPLY=( $(compgen -d -- \"$cur\") )\n",
+			"for ext in 'yaml' 'json'; do",
+			"COMPREPLY=( $('app' __complete 'region' \"$cur\" 2>/dev/null) )",
+			"compgen -W '--config --data --log-level --region -v'",
+		}},
+		{"zsh", []string{
+			"#compdef app\n",
+			"'--log-level[Log level]:log-level:(debug info)'",
+			"'--config[Config file]:config:_files -g \"*.(yaml|json)\"'",
+			"'--data[Data directory]:data:_files -/'",
+			"'--region[Cloud region]:region:{_app_dynamic region}'",
+			"'-v[Verbose output]'",
+			"compdef _app 'app'",
+		}},
+		{"fish", []string{
+			"complete -c 'app' -l 'log-level' -d 'Log level' -x -a 'debug info'\n",
+			"complete -c 'app' -l 'config' -d 'Config file' -x -a '(__fish_complete_suffix .yaml .json)'\n",
+			"complete -c 'app' -l 'data' -d 'Data directory' -x -a '(__fish_complete_directories (commandline -ct))'\n",
+			"complete -c 'app' -l 'region' -d 'Cloud region' -x -a '(app __complete region (commandline -ct))'\n",
+			"complete -c 'app' -s 'v' -d 'Verbose output'\n",
+		}},
+		{"powershell", []string{
+			"Register-ArgumentCompleter -Native -CommandName 'app' -ScriptBlock {",
+			"{$_ -in @('-log-level', '--log-level')} { $values = @('debug', 'info') }",
+			"{$_ -in @('-region', '--region')} { $values = @(& 'app' __complete 'region' $wordToComplete 2>$null) }",
+			"Get-ChildItem -Directory -Path",
+			"@('.yaml', '.json') -contains $_.Extension",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := GenerateCompletion(fs, tt.shell, &buf); err != nil {
+				t.Fatalf("GenerateCompletion(%s) failed: %v", tt.shell, err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("GenerateCompletion(%s) missing %q in:\n%s", tt.shell, want, out)
+				}
+			}
+			if strings.Contains(out, "internal") {
+				t.Errorf("GenerateCompletion(%s) should omit hidden flags", tt.shell)
+			}
+		})
+	}
+}
+
+func TestGenerateCompletion_UnsupportedShell(t *testing.T) {
+	err := GenerateCompletion(flag.NewFlagSet("app", flag.ContinueOnError), "tcsh", &bytes.Buffer{})
+	if !errors.Is(err, ErrUnsupportedShell) {
+		t.Errorf("GenerateCompletion(tcsh) = %v, want ErrUnsupportedShell", err)
+	}
+}
+
+func TestGenerateCompletion_BashSyntax(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	var buf bytes.Buffer
+	if err := GenerateCompletion(newCompletionTestFlagSet(), "bash", &buf); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(t.TempDir(), "app.bash")
+	if err := os.WriteFile(script, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(bash, "-n", script).CombinedOutput(); err != nil {
+		t.Errorf("bash -n failed: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateCompletionPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("tool", pflag.ContinueOnError)
+	fs.StringP("output", "o", "", "Output file")
+	fs.BoolP("quiet", "q", false, "Quiet")
+	fs.Bool("v", false, "Verbose")
+	MarkFilePflag(fs, "output")
+	MarkDirPflag(fs, "quiet")
+
+	var buf bytes.Buffer
+	if err := GenerateCompletionPflag(fs, "bash", &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"        -o|--output)\n            COMPREPLY=( $(compgen -f -- \"$cur\") )\n",
+		"compgen -W '-o --output -q --quiet --v'",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("bash script missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "-q|--quiet)") {
+		t.Error("bash script should not complete values for bool flags")
+	}
+
+	buf.Reset()
+	if err := GenerateCompletionPflag(fs, "zsh", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "'(-o --output)'{-o,--output}'[Output file]:output:_files'"; !strings.Contains(buf.String(), want) {
+		t.Errorf("zsh script missing %q in:\n%s", want, buf.String())
+	}
+
+	// A one-letter long name is "--v" for pflag, never "-v".
+	buf.Reset()
+	if err := GenerateCompletionPflag(fs, "fish", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "-l 'v'") || strings.Contains(out, "-s 'v'") {
+		t.Errorf("fish script should offer v as a long option:\n%s", out)
+	}
+}
+
+func TestHandleCompletion(t *testing.T) {
+	fs := newCompletionTestFlagSet()
+	tests := []struct {
+		name    string
+		args    []string
+		handled bool
+		want    string
+	}{
+		{"not a completion request", []string{"-v"}, false, ""},
+		{"empty args", nil, false, ""},
+		{"dynamic", []string{CompleteCommand, "region", "us-"}, true, "us-east\nus-west\n"},
+		{"dashed flag name", []string{CompleteCommand, "--region"}, true, "eu-west\nus-east\nus-west\n"},
+		{"enum", []string{CompleteCommand, "log-level", "d"}, true, "debug\n"},
+		{"no completion", []string{CompleteCommand, "data", ""}, true, ""},
+		{"unknown flag", []string{CompleteCommand, "nope", ""}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if got := HandleCompletion(fs, tt.args, &buf); got != tt.handled {
+				t.Errorf("HandleCompletion() = %v, want %v", got, tt.handled)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	pfs := pflag.NewFlagSet("tool", pflag.ContinueOnError)
+	pfs.String("zone", "", "Zone")
+	RegisterCompletionPflag(pfs, "zone", func(string) []string { return []string{"a", "b"} })
+	var buf bytes.Buffer
+	if !HandleCompletionPflag(pfs, []string{CompleteCommand, "zone", ""}, &buf) || buf.String() != "a\nb\n" {
+		t.Errorf("HandleCompletionPflag() output = %q", buf.String())
+	}
+}
+
+func TestGenerateCompletion_QuotedValues(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	Enum(fs, "mode", "", []string{"a b", "it's", "x:y", "$HOME"}, true, "Mode: `quoted` 'value'")
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{`for v in 'a b' 'it'\''s' 'x:y' '$HOME'; do`}},
+		{"zsh", []string{`'--mode[Mode\: quoted '\''value'\'']:mode:(a\ b it\'\''s x\:y \$HOME)'`}},
+		{"fish", []string{`-d 'Mode: quoted \'value\'' -x -a '\'a b\' \'it\\\'s\' \'x:y\' \'$HOME\''`}},
+		{"powershell", []string{`$values = @('a b', 'it''s', 'x:y', '$HOME')`}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := GenerateCompletion(fs, tt.shell, &buf); err != nil {
+			t.Fatalf("GenerateCompletion(%s) failed: %v", tt.shell, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("GenerateCompletion(%s) missing %s in:\n%s", tt.shell, want, buf.String())
+			}
+		}
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	var buf bytes.Buffer
+	if err := GenerateCompletion(fs, "bash", &buf); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(t.TempDir(), "app.bash")
+	if err := os.WriteFile(script, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run := `complete() { :; }; source "$1"; COMP_WORDS=(app --mode "$2"); COMP_CWORD=2; _app_completions; printf '%s\n' "${COMPREPLY[@]}"`
+	for cur, want := range map[string]string{"": "a b\nit's\nx:y\n$HOME\n", "a": "a b\n", "$": "$HOME\n"} {
+		out, err := exec.Command(bash, "-c", run, "bash", script, cur).CombinedOutput()
+		if err != nil || string(out) != want {
+			t.Errorf("bash completion of %q = %q, %v, want %q", cur, out, err, want)
+		}
+	}
+}
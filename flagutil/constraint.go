@@ -0,0 +1,207 @@
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/soulteary/cli-kit/env"
+	"github.com/spf13/pflag"
+)
+
+// ConstraintKind identifies a flag group constraint.
+type ConstraintKind int
+
+const (
+	// Required flags must each be given.
+	Required ConstraintKind = iota + 1
+	// MutuallyExclusive flags cannot be given together.
+	MutuallyExclusive
+	// RequiredTogether flags must be given all or none.
+	RequiredTogether
+	// OneRequired needs at least one of the flags.
+	OneRequired
+)
+
+// constraint is a rule registered on a FlagSet.
+type constraint struct {
+	kind  ConstraintKind
+	names []string
+}
+
+// ConstraintError describes one violated constraint.
+type ConstraintError struct {
+	Kind ConstraintKind
+	// Flags are the flags the violation is about: the missing ones for
+	// Required, RequiredTogether and OneRequired, the conflicting ones for
+	// MutuallyExclusive.
+	Flags []string
+}
+
+func (e *ConstraintError) Error() string {
+	names := displayNames(e.Flags)
+	switch e.Kind {
+	case Required:
+		return fmt.Sprintf("required flag %s is not set", names[0])
+	case MutuallyExclusive:
+		return fmt.Sprintf("flags %s cannot be used together", joinWords(names, "and"))
+	case RequiredTogether:
+		return fmt.Sprintf("flags must be used together: missing %s", joinWords(names, "and"))
+	case OneRequired:
+		return fmt.Sprintf("one of %s is required", joinWords(names, "or"))
+	}
+	return "flag constraint violated"
+}
+
+// MarkRequired requires each named flag to be set on the command line or
+// through its bound environment variable (see BindEnv).
+func MarkRequired(fs *flag.FlagSet, names ...string) {
+	addConstraint(fs, Required, names)
+}
+
+// MarkRequiredPflag is MarkRequired for a pflag.FlagSet.
+func MarkRequiredPflag(fs *pflag.FlagSet, names ...string) {
+	addConstraint(fs, Required, names)
+}
+
+// MarkMutuallyExclusive allows at most one of the named flags to be set.
+func MarkMutuallyExclusive(fs *flag.FlagSet, names ...string) {
+	addConstraint(fs, MutuallyExclusive, names)
+}
+
+// MarkMutuallyExclusivePflag is MarkMutuallyExclusive for a pflag.FlagSet.
+func MarkMutuallyExclusivePflag(fs *pflag.FlagSet, names ...string) {
+	addConstraint(fs, MutuallyExclusive, names)
+}
+
+// MarkRequiredTogether requires the named flags to be set all together or not at all.
+func MarkRequiredTogether(fs *flag.FlagSet, names ...string) {
+	addConstraint(fs, RequiredTogether, names)
+}
+
+// MarkRequiredTogetherPflag is MarkRequiredTogether for a pflag.FlagSet.
+func MarkRequiredTogetherPflag(fs *pflag.FlagSet, names ...string) {
+	addConstraint(fs, RequiredTogether, names)
+}
+
+// MarkOneRequired requires at least one of the named flags to be set.
+func MarkOneRequired(fs *flag.FlagSet, names ...string) {
+	addConstraint(fs, OneRequired, names)
+}
+
+// MarkOneRequiredPflag is MarkOneRequired for a pflag.FlagSet.
+func MarkOneRequiredPflag(fs *pflag.FlagSet, names ...string) {
+	addConstraint(fs, OneRequired, names)
+}
+
+func addConstraint(key any, kind ConstraintKind, names []string) {
+	c := constraint{kind: kind, names: append([]string(nil), names...)}
+	updateMeta(key, func(m *setMeta) {
+		if kind == Required {
+			// One rule per flag keeps errors and help per flag.
+			for _, name := range c.names {
+				m.constraints = append(m.constraints, constraint{kind: Required, names: []string{name}})
+			}
+			return
+		}
+		m.constraints = append(m.constraints, c)
+	})
+}
+
+// CheckConstraints verifies the constraints registered on fs after Parse.
+// A flag counts as set if it was given on the command line or its bound
+// environment variable is non-empty, so it can run before or after
+// EnvBinding.Apply. All violations are returned together as
+// *ConstraintError values joined with errors.Join.
+func CheckConstraints(fs *flag.FlagSet) error {
+	return checkConstraints(stdFlagSet{fs})
+}
+
+// CheckConstraintsPflag is CheckConstraints for a pflag.FlagSet.
+func CheckConstraintsPflag(fs *pflag.FlagSet) error {
+	return checkConstraints(pflagFlagSet{fs})
+}
+
+func checkConstraints(fs flagSet) error {
+	var errs []error
+	for _, c := range flagConstraints(fs.key()) {
+		var set, unset []string
+		for _, name := range c.names {
+			if isFlagGiven(fs, name) {
+				set = append(set, name)
+			} else {
+				unset = append(unset, name)
+			}
+		}
+		switch {
+		case c.kind == Required && len(unset) > 0,
+			c.kind == RequiredTogether && len(set) > 0 && len(unset) > 0,
+			c.kind == OneRequired && len(set) == 0:
+			errs = append(errs, &ConstraintError{Kind: c.kind, Flags: unset})
+		case c.kind == MutuallyExclusive && len(set) > 1:
+			errs = append(errs, &ConstraintError{Kind: c.kind, Flags: set})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isFlagGiven reports whether a flag was set on the command line or has a
+// non-empty bound environment variable.
+func isFlagGiven(fs flagSet, name string) bool {
+	if fs.changed(name) {
+		return true
+	}
+	envKey := boundEnvKey(fs.key(), name)
+	return envKey != "" && env.Get(envKey, "") != ""
+}
+
+func flagConstraints(key any) []constraint {
+	var constraints []constraint
+	readMeta(key, func(m *setMeta) { constraints = append(constraints, m.constraints...) })
+	return constraints
+}
+
+// isRequired reports whether a Required constraint names flagName.
+func isRequired(constraints []constraint, flagName string) bool {
+	for _, c := range constraints {
+		if c.kind == Required && c.names[0] == flagName {
+			return true
+		}
+	}
+	return false
+}
+
+// describe returns the help line for a group constraint, or "" for Required,
+// which is shown on the flag itself.
+func (c constraint) describe() string {
+	names := displayNames(c.names)
+	switch c.kind {
+	case MutuallyExclusive:
+		return joinWords(names, "and") + " are mutually exclusive"
+	case RequiredTogether:
+		return joinWords(names, "and") + " must be used together"
+	case OneRequired:
+		return "one of " + joinWords(names, "or") + " is required"
+	}
+	return ""
+}
+
+func displayNames(names []string) []string {
+	shown := make([]string, len(names))
+	for i, name := range names {
+		shown[i] = flagDisplayName(name)
+	}
+	return shown
+}
+
+// joinWords joins words as "a", "a and b" or "a, b and c".
+func joinWords(words []string, conj string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " " + conj + " " + words[len(words)-1]
+}
@@ -0,0 +1,158 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newConstraintFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("config", "", "Config file")
+	fs.String("tls-cert", "", "TLS certificate")
+	fs.String("tls-key", "", "TLS key")
+	fs.String("token", "", "API token")
+	fs.String("token-file", "", "API token file")
+	fs.String("id", "", "Object ID")
+	fs.String("name", "", "Object name")
+	MarkRequired(fs, "config")
+	MarkRequiredTogether(fs, "tls-cert", "tls-key")
+	MarkMutuallyExclusive(fs, "token", "token-file")
+	MarkOneRequired(fs, "id", "name")
+	return fs
+}
+
+func TestCheckConstraints(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"all satisfied", []string{"-config", "c", "-id", "1"}, nil},
+		{"pair given", []string{"-config", "c", "-name", "n", "-tls-cert", "a", "-tls-key", "b"}, nil},
+		{"missing required", []string{"-id", "1"}, []string{"required flag --config is not set"}},
+		{"half a pair", []string{"-config", "c", "-id", "1", "-tls-key", "b"},
+			[]string{"flags must be used together: missing --tls-cert"}},
+		{"exclusive", []string{"-config", "c", "-id", "1", "-token", "t", "-token-file", "f"},
+			[]string{"flags --token and --token-file cannot be used together"}},
+		{"all violations", []string{"-token", "t", "-token-file", "f"}, []string{
+			"required flag --config is not set",
+			"flags --token and --token-file cannot be used together",
+			"one of --id or --name is required",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newConstraintFlagSet()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := CheckConstraints(fs)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("CheckConstraints() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != strings.Join(tt.want, "\n") {
+				t.Errorf("CheckConstraints() = %v, want %q", err, tt.want)
+			}
+			var ce *ConstraintError
+			if !errors.As(err, &ce) {
+				t.Errorf("CheckConstraints() error should contain a *ConstraintError")
+			}
+		})
+	}
+}
+
+func TestCheckConstraints_Env(t *testing.T) {
+	t.Setenv("APP_CONFIG", "app.json")
+	t.Setenv("APP_ID", "")
+	t.Setenv("APP_NAME", "")
+	t.Setenv("APP_TLS_CERT", "")
+	t.Setenv("APP_TLS_KEY", "key.pem")
+	t.Setenv("APP_TOKEN", "")
+	t.Setenv("APP_TOKEN_FILE", "")
+	fs := newConstraintFlagSet()
+	BindEnv(fs, "APP")
+	if err := fs.Parse([]string{"-name", "n", "-tls-cert", "cert.pem"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckConstraints(fs); err != nil {
+		t.Errorf("CheckConstraints() should count env-bound values, got %v", err)
+	}
+
+	t.Setenv("APP_TOKEN", "t")
+	if err := fs.Set("token-file", "f"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckConstraints(fs); err == nil || !strings.Contains(err.Error(), "--token and --token-file") {
+		t.Errorf("CheckConstraints() = %v, want exclusive violation from env and CLI", err)
+	}
+}
+
+func TestCheckConstraintsPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.StringP("output", "o", "", "Output file")
+	fs.Bool("json", false, "JSON output")
+	fs.Bool("yaml", false, "YAML output")
+	MarkRequiredPflag(fs, "output")
+	MarkMutuallyExclusivePflag(fs, "json", "yaml")
+
+	if err := fs.Parse([]string{"-o", "out", "--json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckConstraintsPflag(fs); err != nil {
+		t.Errorf("CheckConstraintsPflag() = %v, want nil", err)
+	}
+	if err := fs.Set("yaml", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckConstraintsPflag(fs); err == nil || err.Error() != "flags --json and --yaml cannot be used together" {
+		t.Errorf("CheckConstraintsPflag() = %v", err)
+	}
+}
+
+func TestConstraintsUsage(t *testing.T) {
+	fs := newConstraintFlagSet()
+	u := NewUsage(fs)
+	u.Width = 100
+
+	var text bytes.Buffer
+	if err := u.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Config file (required)\n",
+		"\nConstraints:\n" +
+			"  --tls-cert and --tls-key must be used together\n" +
+			"  --token and --token-file are mutually exclusive\n" +
+			"  one of --id or --name is required\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("WriteText() missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var md bytes.Buffer
+	if err := u.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| Config file (required) |", "### Constraints\n\n- --tls-cert and --tls-key must be used together\n"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("WriteMarkdown() missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var man bytes.Buffer
+	if err := u.WriteMan(&man); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(man.String(), ".SH CONSTRAINTS\n") {
+		t.Errorf("WriteMan() missing constraints section:\n%s", man.String())
+	}
+}
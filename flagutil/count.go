@@ -0,0 +1,59 @@
+package flagutil
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// countValue is an int flag incremented each time the flag is given.
+type countValue int
+
+// Set increments the count for a bare flag (-v), sets it to an explicit
+// number (-v=3) and resets it with -v=false.
+func (c *countValue) Set(s string) error {
+	if n, err := strconv.Atoi(s); err == nil {
+		*c = countValue(n)
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if b {
+		*c++
+	} else {
+		*c = 0
+	}
+	return nil
+}
+
+func (c *countValue) String() string { return strconv.Itoa(int(*c)) }
+
+// IsBoolFlag lets the flag be given without a value.
+func (c *countValue) IsBoolFlag() bool { return true }
+
+// Get implements flag.Getter.
+func (c *countValue) Get() any { return int(*c) }
+
+// Count defines a flag that counts how often it is given, so -v -v -v yields 3,
+// and returns a pointer to the count. "-v=N" sets the count directly. With
+// ParseInterspersed a one-letter count flag, or one reached through a shorthand
+// registered with Shorthands, can also be repeated in a bundle such as -vvv.
+func Count(fs *flag.FlagSet, name, usage string) *int {
+	p := new(int)
+	fs.Var((*countValue)(p), name, usage)
+	return p
+}
+
+// CountPflag is Count for a pflag.FlagSet; pflag accepts -vvv natively.
+func CountPflag(fs *pflag.FlagSet, name, shorthand, usage string) *int {
+	return fs.CountP(name, shorthand, usage)
+}
+
+// isCountValue reports whether v was defined with Count.
+func isCountValue(v flag.Value) bool {
+	_, ok := v.(*countValue)
+	return ok
+}
@@ -0,0 +1,61 @@
+package flagutil
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		flag string
+		want int
+	}{
+		{"unset", nil, "v", 0},
+		{"repeated", []string{"-v", "-v", "-v"}, "v", 3},
+		{"bundle", []string{"-vvv", "file"}, "v", 3},
+		{"bundle after positional", []string{"file", "-vv", "-v"}, "v", 3},
+		{"explicit", []string{"-v", "-v=5"}, "v", 5},
+		{"reset", []string{"-v", "-v=false", "-v"}, "v", 1},
+		{"shorthand bundle", []string{"--debug", "-dd"}, "debug", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			counts := map[string]*int{
+				"v":     Count(fs, "v", "Verbosity"),
+				"debug": Count(fs, "debug", "Debug level"),
+			}
+			Shorthands(fs, map[string]string{"d": "debug"})
+			if err := ParseInterspersed(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := *counts[tt.flag]; got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+			if got := GetInt(fs, tt.flag, 0); got != tt.want {
+				t.Errorf("GetInt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	Count(fs, "v", "Verbosity")
+	if err := fs.Parse([]string{"-v", "-v"}); err != nil || GetInt(fs, "v", 0) != 2 {
+		t.Errorf("fs.Parse(-v -v) = %v, GetInt() = %d, want 2", err, GetInt(fs, "v", 0))
+	}
+}
+
+func TestCountPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	v := CountPflag(fs, "verbose", "v", "Verbosity")
+	if err := fs.Parse([]string{"-vvv", "--verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	if *v != 4 || GetIntPflag(fs, "verbose", 0) != 4 {
+		t.Errorf("count = %d, GetIntPflag() = %d, want 4", *v, GetIntPflag(fs, "verbose", 0))
+	}
+}
@@ -0,0 +1,274 @@
+package flagutil
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect written by WriteJSONSchema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// FlagSetDescription is the structured metadata of a FlagSet returned by
+// Describe. Its JSON form is stable: fields keep their names and order, flags
+// are sorted by name and empty fields are omitted, so it can be snapshot-tested.
+type FlagSetDescription struct {
+	Name  string            `json:"name"`
+	Flags []FlagDescription `json:"flags"`
+}
+
+// FlagDescription describes one flag.
+type FlagDescription struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	// Type is the pflag type name ("string", "int", "bool", "duration",
+	// "stringSlice", "count", ...); standard flags use the same names.
+	Type string `json:"type"`
+	// Default is the default value as text; secrets never report one.
+	Default string `json:"default,omitempty"`
+	// Usage is the usage text without the env and enum annotations.
+	Usage string `json:"usage,omitempty"`
+	// Env is the environment variable bound with BindEnv.
+	Env string `json:"env,omitempty"`
+	// Enum lists the allowed values of Enum flags.
+	Enum []string `json:"enum,omitempty"`
+	// Aliases are other names defined with Alias or Shorthands.
+	Aliases []string `json:"aliases,omitempty"`
+	// Group is the help group set with SetGroup.
+	Group     string `json:"group,omitempty"`
+	Required  bool   `json:"required,omitempty"`
+	Negatable bool   `json:"negatable,omitempty"`
+	Secret    bool   `json:"secret,omitempty"`
+	// Hidden is set for flags hidden from help (Hide, MarkHidden); deprecated
+	// flags report Deprecated only.
+	Hidden     bool   `json:"hidden,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Describe returns the metadata of every flag defined on fs, including hidden
+// and deprecated ones. Aliases and --no-name flags are folded into the flag
+// they belong to.
+func Describe(fs *flag.FlagSet) *FlagSetDescription {
+	return describe(stdFlagSet{fs})
+}
+
+// DescribePflag is Describe for a pflag.FlagSet.
+func DescribePflag(fs *pflag.FlagSet) *FlagSetDescription {
+	return describe(pflagFlagSet{fs})
+}
+
+func describe(fs flagSet) *FlagSetDescription {
+	var (
+		hidden    = make(map[string]bool)
+		groups    = make(map[string]string)
+		aliases   = make(map[string][]string)
+		negations = make(map[string]bool)
+	)
+	readMeta(fs.key(), func(m *setMeta) {
+		for name := range m.hidden {
+			hidden[name] = true
+		}
+		for name, group := range m.groups {
+			groups[name] = group
+		}
+		for alias, canonical := range m.aliases {
+			aliases[canonical] = append(aliases[canonical], alias)
+		}
+		for name := range m.negations {
+			negations[name] = true
+		}
+	})
+	constraints := flagConstraints(fs.key())
+	u := &Usage{fs: fs}
+
+	d := &FlagSetDescription{Name: fs.name(), Flags: []FlagDescription{}}
+	for _, f := range fs.all() {
+		if isAlias(fs.key(), f.Name) || negations[f.Name] {
+			continue
+		}
+		e := u.entry(f)
+		_, secret := f.Value.(*SecretValue)
+		fd := FlagDescription{
+			Name:       f.Name,
+			Shorthand:  f.Shorthand,
+			Type:       flagType(f.Value),
+			Default:    f.DefValue,
+			Usage:      e.usage,
+			Env:        e.env,
+			Enum:       e.allowed,
+			Group:      groups[f.Name],
+			Required:   isRequired(constraints, f.Name),
+			Negatable:  e.negatable,
+			Secret:     secret,
+			Hidden:     (hidden[f.Name] || f.Hidden) && f.Deprecated == "",
+			Deprecated: f.Deprecated,
+		}
+		if secret {
+			fd.Default = ""
+		}
+		for _, alias := range aliases[f.Name] {
+			if alias != f.Shorthand {
+				fd.Aliases = append(fd.Aliases, alias)
+			}
+		}
+		slices.Sort(fd.Aliases)
+		d.Flags = append(d.Flags, fd)
+	}
+	return d
+}
+
+// flagType returns the pflag type name of v, deriving it from the dynamic
+// value for standard flags.
+func flagType(v flag.Value) string {
+	if t, ok := v.(interface{ Type() string }); ok {
+		return t.Type()
+	}
+	if isCountValue(v) {
+		return "count"
+	}
+	if isBoolValue(v) {
+		return "bool"
+	}
+	if g, ok := v.(flag.Getter); ok {
+		switch g.Get().(type) {
+		case int:
+			return "int"
+		case int64:
+			return "int64"
+		case uint:
+			return "uint"
+		case uint64:
+			return "uint64"
+		case float64:
+			return "float64"
+		case time.Duration:
+			return "duration"
+		}
+	}
+	return "string"
+}
+
+// WriteJSON writes d as indented JSON.
+func (d *FlagSetDescription) WriteJSON(w io.Writer) error {
+	return writeIndentedJSON(w, d)
+}
+
+// jsonSchema is a JSON Schema document for a config file holding flag values.
+type jsonSchema struct {
+	Schema               string                     `json:"$schema"`
+	Title                string                     `json:"title,omitempty"`
+	Type                 string                     `json:"type"`
+	Properties           map[string]*schemaProperty `json:"properties"`
+	Required             []string                   `json:"required,omitempty"`
+	AdditionalProperties bool                       `json:"additionalProperties"`
+}
+
+type schemaProperty struct {
+	Type                 string          `json:"type"`
+	Description          string          `json:"description,omitempty"`
+	Default              any             `json:"default,omitempty"`
+	Enum                 []string        `json:"enum,omitempty"`
+	Items                *schemaProperty `json:"items,omitempty"`
+	AdditionalProperties *schemaProperty `json:"additionalProperties,omitempty"`
+	Deprecated           bool            `json:"deprecated,omitempty"`
+	WriteOnly            bool            `json:"writeOnly,omitempty"`
+	Env                  string          `json:"x-env,omitempty"`
+}
+
+// WriteJSONSchema writes a JSON Schema (draft 2020-12) for a config file
+// whose keys are the flag names, e.g. {"port": 8080, "tags": ["a"]}.
+// Flag types map to JSON types (integers, numbers, booleans, arrays for slice
+// flags, objects for map flags), defaults are typed, Enum values become
+// "enum", required flags are listed in "required" and secrets are "writeOnly".
+// The bound environment variable is kept as "x-env".
+func (d *FlagSetDescription) WriteJSONSchema(w io.Writer) error {
+	s := jsonSchema{
+		Schema:     jsonSchemaDraft,
+		Title:      d.Name,
+		Type:       "object",
+		Properties: make(map[string]*schemaProperty),
+	}
+	for _, f := range d.Flags {
+		p := schemaFor(f.Type)
+		p.Description = f.Usage
+		p.Default = schemaDefault(p, f.Default)
+		p.Enum = f.Enum
+		p.Deprecated = f.Deprecated != ""
+		p.WriteOnly = f.Secret
+		p.Env = f.Env
+		s.Properties[f.Name] = p
+		if f.Required {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return writeIndentedJSON(w, s)
+}
+
+// schemaFor maps a pflag type name to a JSON Schema type.
+func schemaFor(typ string) *schemaProperty {
+	switch {
+	case strings.HasSuffix(typ, "Slice") || strings.HasSuffix(typ, "Array"):
+		elem := strings.TrimSuffix(strings.TrimSuffix(typ, "Slice"), "Array")
+		return &schemaProperty{Type: "array", Items: schemaFor(elem)}
+	case typ == "bool":
+		return &schemaProperty{Type: "boolean"}
+	case typ == "count" || strings.HasPrefix(typ, "int") || strings.HasPrefix(typ, "uint"):
+		return &schemaProperty{Type: "integer"}
+	case strings.HasPrefix(typ, "float"):
+		return &schemaProperty{Type: "number"}
+	case strings.HasPrefix(typ, "stringTo"):
+		return &schemaProperty{Type: "object", AdditionalProperties: schemaFor(strings.ToLower(strings.TrimPrefix(typ, "stringTo")))}
+	}
+	return &schemaProperty{Type: "string"}
+}
+
+// schemaDefault converts a default value to the JSON type of p. Defaults that
+// cannot be converted, and empty ones, are omitted.
+func schemaDefault(p *schemaProperty, def string) any {
+	switch p.Type {
+	case "boolean":
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(def, 64); err == nil {
+			return n
+		}
+	case "array":
+		inner, ok := strings.CutPrefix(def, "[")
+		if inner, ok = strings.CutSuffix(inner, "]"); !ok || inner == "" {
+			return nil
+		}
+		var items []any
+		for _, item := range strings.Split(inner, ",") {
+			v := schemaDefault(p.Items, item)
+			if v == nil {
+				return nil
+			}
+			items = append(items, v)
+		}
+		return items
+	case "string":
+		if def != "" {
+			return def
+		}
+	}
+	return nil
+}
+
+func writeIndentedJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,212 @@
+package flagutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestDescribe(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 8080, "Listen `port`")
+	fs.Duration("timeout", 5*time.Second, "Request timeout")
+	Enum(fs, "log-level", "info", []string{"debug", "info"}, false, "Log level")
+	Secret(fs, "password", "Password")
+	NegatableBool(fs, "color", true, "Colorize output")
+	fs.String("name", "", "Name")
+	fs.String("old", "", "Old")
+	Alias(fs, "n", "name")
+	Shorthands(fs, map[string]string{"p": "port"})
+	Deprecate(fs, "old", "use --name")
+	Hide(fs, "timeout")
+	SetGroup(fs, "Network", "port", "timeout")
+	MarkRequired(fs, "name")
+	BindEnv(fs, "APP")
+
+	var got bytes.Buffer
+	if err := Describe(fs).WriteJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "name": "app",
+  "flags": [
+    {
+      "name": "color",
+      "type": "bool",
+      "default": "true",
+      "usage": "Colorize output",
+      "env": "APP_COLOR",
+      "negatable": true
+    },
+    {
+      "name": "log-level",
+      "type": "string",
+      "default": "info",
+      "usage": "Log level",
+      "env": "APP_LOG_LEVEL",
+      "enum": [
+        "debug",
+        "info"
+      ]
+    },
+    {
+      "name": "name",
+      "type": "string",
+      "usage": "Name",
+      "env": "APP_NAME",
+      "aliases": [
+        "n"
+      ],
+      "required": true
+    },
+    {
+      "name": "old",
+      "type": "string",
+      "usage": "Old",
+      "env": "APP_OLD",
+      "deprecated": "use --name"
+    },
+    {
+      "name": "password",
+      "type": "secret",
+      "usage": "Password",
+      "env": "APP_PASSWORD",
+      "secret": true
+    },
+    {
+      "name": "port",
+      "shorthand": "p",
+      "type": "int",
+      "default": "8080",
+      "usage": "Listen port",
+      "env": "APP_PORT",
+      "group": "Network"
+    },
+    {
+      "name": "timeout",
+      "type": "duration",
+      "default": "5s",
+      "usage": "Request timeout",
+      "env": "APP_TIMEOUT",
+      "group": "Network",
+      "hidden": true
+    }
+  ]
+}
+`
+	if got.String() != want {
+		t.Errorf("WriteJSON() =\n%s\nwant\n%s", got.String(), want)
+	}
+}
+
+func TestDescribe_JSONSchema(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 8080, "Listen port")
+	Enum(fs, "mode", "fast", []string{"fast", "safe"}, false, "Mode")
+	Secret(fs, "token", "API token")
+	MarkRequired(fs, "token")
+
+	var got bytes.Buffer
+	if err := Describe(fs).WriteJSONSchema(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "app",
+  "type": "object",
+  "properties": {
+    "mode": {
+      "type": "string",
+      "description": "Mode",
+      "default": "fast",
+      "enum": [
+        "fast",
+        "safe"
+      ]
+    },
+    "port": {
+      "type": "integer",
+      "description": "Listen port",
+      "default": 8080
+    },
+    "token": {
+      "type": "string",
+      "description": "API token",
+      "writeOnly": true
+    }
+  },
+  "required": [
+    "token"
+  ],
+  "additionalProperties": false
+}
+`
+	if got.String() != want {
+		t.Errorf("WriteJSONSchema() =\n%s\nwant\n%s", got.String(), want)
+	}
+}
+
+func TestDescribePflag_JSONSchema(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.StringSlice("tags", []string{"a", "b"}, "Tags")
+	fs.IntSlice("ports", []int{80}, "Ports")
+	fs.StringToString("labels", nil, "Labels")
+	fs.Float64("ratio", 0.5, "Ratio")
+	fs.CountP("verbose", "v", "Verbosity")
+	fs.String("legacy", "", "Legacy")
+	_ = fs.MarkDeprecated("legacy", "do not use")
+
+	d := DescribePflag(fs)
+	byName := map[string]FlagDescription{}
+	for _, f := range d.Flags {
+		byName[f.Name] = f
+	}
+	if f := byName["verbose"]; f.Shorthand != "v" || f.Type != "count" {
+		t.Errorf("verbose = %+v", f)
+	}
+	if f := byName["legacy"]; f.Hidden || f.Deprecated != "do not use" {
+		t.Errorf("legacy = %+v, want deprecated but not hidden", f)
+	}
+
+	var buf bytes.Buffer
+	if err := d.WriteJSONSchema(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type                 string          `json:"type"`
+			Default              json.RawMessage `json:"default"`
+			Items                *struct{ Type string }
+			AdditionalProperties *struct{ Type string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	p := schema.Properties
+	checks := []struct {
+		name, typ, def string
+		ok             bool
+	}{
+		{"tags", "array", `["a","b"]`, p["tags"].Items != nil && p["tags"].Items.Type == "string"},
+		{"ports", "array", `[80]`, p["ports"].Items != nil && p["ports"].Items.Type == "integer"},
+		{"labels", "object", ``, p["labels"].AdditionalProperties != nil && p["labels"].AdditionalProperties.Type == "string"},
+		{"ratio", "number", `0.5`, true},
+		{"verbose", "integer", `0`, true},
+	}
+	for _, c := range checks {
+		var def bytes.Buffer
+		if len(p[c.name].Default) > 0 {
+			if err := json.Compact(&def, p[c.name].Default); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p[c.name].Type != c.typ || def.String() != c.def || !c.ok {
+			t.Errorf("%s: type %s, default %s, want type %s, default %s", c.name, p[c.name].Type, def.String(), c.typ, c.def)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// EnumValue is a flag value restricted to a fixed set of allowed strings.
+// It implements flag.Value, flag.Getter and pflag.Value, and validates input
+// with validator.ValidateEnum in Set so that fs.Parse fails on the first
+// invalid value instead of leaving the check to post-parse resolution.
+type EnumValue struct {
+	value         string
+	allowed       []string
+	caseSensitive bool
+}
+
+// NewEnumValue creates an EnumValue with the given default and allowed values.
+// An empty default means "no value"; a non-empty default must be one of allowed.
+func NewEnumValue(defaultValue string, allowed []string, caseSensitive bool) (*EnumValue, error) {
+	e := &EnumValue{
+		allowed:       append([]string(nil), allowed...),
+		caseSensitive: caseSensitive,
+	}
+	if defaultValue != "" {
+		if err := e.Set(defaultValue); err != nil {
+			return nil, err
+		}
+	} else if len(allowed) == 0 {
+		return nil, fmt.Errorf("allowed values list cannot be empty")
+	}
+	return e, nil
+}
+
+// String returns the current value.
+func (e *EnumValue) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.value
+}
+
+// Set validates s against the allowed values. When matching case-insensitively
+// the stored value is the spelling from the allowed list, so callers can
+// compare against their own constants.
+func (e *EnumValue) Set(s string) error {
+	if err := validator.ValidateEnum(s, e.allowed, e.caseSensitive); err != nil {
+		return err
+	}
+	e.value = e.canonical(s)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (e *EnumValue) Type() string {
+	return "string"
+}
+
+// Get implements flag.Getter.
+func (e *EnumValue) Get() any {
+	return e.value
+}
+
+// AllowedValues returns a copy of the allowed values, e.g. for help output
+// or shell completion.
+func (e *EnumValue) AllowedValues() []string {
+	return append([]string(nil), e.allowed...)
+}
+
+// canonical returns the allowed spelling matching s.
+func (e *EnumValue) canonical(s string) string {
+	if e.caseSensitive {
+		return s
+	}
+	for _, allowed := range e.allowed {
+		if strings.EqualFold(s, allowed) {
+			return allowed
+		}
+	}
+	return s
+}
+
+// EnumValuer is implemented by flag values that accept a fixed set of values.
+type EnumValuer interface {
+	AllowedValues() []string
+}
+
+// Enum defines an enum flag on fs and returns its value.
+// The allowed values are appended to usage, and Set rejects anything else.
+// It panics if defaultValue is not one of allowed, like flag does for
+// other definition errors.
+func Enum(fs *flag.FlagSet, name, defaultValue string, allowed []string, caseSensitive bool, usage string) *EnumValue {
+	e := mustEnumValue(name, defaultValue, allowed, caseSensitive)
+	fs.Var(e, name, enumUsage(usage, allowed))
+	return e
+}
+
+// EnumPflag defines an enum flag on a pflag.FlagSet and returns its value.
+// shorthand may be empty.
+func EnumPflag(fs *pflag.FlagSet, name, shorthand, defaultValue string, allowed []string, caseSensitive bool, usage string) *EnumValue {
+	e := mustEnumValue(name, defaultValue, allowed, caseSensitive)
+	fs.VarP(e, name, shorthand, enumUsage(usage, allowed))
+	return e
+}
+
+func mustEnumValue(name, defaultValue string, allowed []string, caseSensitive bool) *EnumValue {
+	e, err := NewEnumValue(defaultValue, allowed, caseSensitive)
+	if err != nil {
+		panic(fmt.Sprintf("flagutil: enum flag %q: %v", name, err))
+	}
+	return e
+}
+
+// enumUsage appends the allowed values to a usage string.
+func enumUsage(usage string, allowed []string) string {
+	suffix := enumUsageSuffix(allowed)
+	if usage == "" {
+		return strings.TrimSpace(suffix)
+	}
+	return usage + suffix
+}
+
+// enumUsageSuffix is the text enumUsage appends, kept separate so renderers
+// that list allowed values themselves can strip it again.
+func enumUsageSuffix(allowed []string) string {
+	return " (one of: " + strings.Join(allowed, ", ") + ")"
+}
@@ -0,0 +1,149 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+func TestEnum(t *testing.T) {
+	allowed := []string{"debug", "info", "warn", "error"}
+
+	t.Run("valid value", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		level := Enum(fs, "log-level", "info", allowed, true, "log level")
+		if level.String() != "info" {
+			t.Errorf("default = %q, want info", level.String())
+		}
+		if err := fs.Parse([]string{"--log-level", "warn"}); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		if level.String() != "warn" {
+			t.Errorf("value = %q, want warn", level.String())
+		}
+		if got := GetString(fs, "log-level", "info"); got != "warn" {
+			t.Errorf("GetString() = %q, want warn", got)
+		}
+	})
+
+	t.Run("invalid value fails parse", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		Enum(fs, "log-level", "info", allowed, true, "log level")
+		err := fs.Parse([]string{"--log-level=verbsoe"})
+		if err == nil {
+			t.Fatal("fs.Parse() should fail for value outside enum")
+		}
+		if !strings.Contains(err.Error(), "log-level") || !strings.Contains(err.Error(), "verbsoe") {
+			t.Errorf("error should name flag and value, got %v", err)
+		}
+	})
+
+	t.Run("case insensitive stores canonical spelling", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		level := Enum(fs, "log-level", "", allowed, false, "log level")
+		if err := fs.Parse([]string{"-log-level", "DEBUG"}); err != nil {
+			t.Fatalf("fs.Parse() failed: %v", err)
+		}
+		if level.String() != "debug" {
+			t.Errorf("value = %q, want debug", level.String())
+		}
+		if level.Get() != "debug" {
+			t.Errorf("Get() = %v, want debug", level.Get())
+		}
+	})
+
+	t.Run("usage lists allowed values", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Enum(fs, "log-level", "info", allowed, true, "log level")
+		f := fs.Lookup("log-level")
+		if f.Usage != "log level (one of: debug, info, warn, error)" {
+			t.Errorf("Usage = %q", f.Usage)
+		}
+		var buf bytes.Buffer
+		fs.SetOutput(&buf)
+		fs.PrintDefaults()
+		if !strings.Contains(buf.String(), "one of: debug, info, warn, error") {
+			t.Errorf("PrintDefaults() missing allowed values: %s", buf.String())
+		}
+	})
+
+	t.Run("allowed values exposed", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Enum(fs, "log-level", "info", allowed, true, "")
+		ev, ok := fs.Lookup("log-level").Value.(EnumValuer)
+		if !ok {
+			t.Fatal("enum flag value should implement EnumValuer")
+		}
+		got := ev.AllowedValues()
+		if strings.Join(got, ",") != "debug,info,warn,error" {
+			t.Errorf("AllowedValues() = %v", got)
+		}
+		got[0] = "mutated"
+		if ev.AllowedValues()[0] != "debug" {
+			t.Error("AllowedValues() should return a copy")
+		}
+		if fs.Lookup("log-level").Usage != "(one of: debug, info, warn, error)" {
+			t.Errorf("Usage with empty text = %q", fs.Lookup("log-level").Usage)
+		}
+	})
+
+	t.Run("invalid default panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Enum() should panic for default outside allowed values")
+			}
+		}()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Enum(fs, "log-level", "trace", allowed, true, "log level")
+	})
+}
+
+func TestNewEnumValue(t *testing.T) {
+	if _, err := NewEnumValue("", nil, true); err == nil {
+		t.Error("NewEnumValue() should reject empty allowed list")
+	}
+	_, err := NewEnumValue("x", []string{"a"}, true)
+	if !errors.Is(err, validator.ErrInvalidEnumValue) {
+		t.Errorf("NewEnumValue() error = %v, want ErrInvalidEnumValue", err)
+	}
+	var nilEnum *EnumValue
+	if nilEnum.String() != "" {
+		t.Error("nil EnumValue String() should be empty")
+	}
+}
+
+func TestEnumPflag(t *testing.T) {
+	allowed := []string{"json", "text"}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	format := EnumPflag(fs, "format", "f", "text", allowed, true, "output format")
+	if err := fs.Parse([]string{"-f", "json"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if format.String() != "json" {
+		t.Errorf("value = %q, want json", format.String())
+	}
+	if format.Type() != "string" {
+		t.Errorf("Type() = %q, want string", format.Type())
+	}
+	if got := GetStringPflag(fs, "format", "text"); got != "json" {
+		t.Errorf("GetStringPflag() = %q, want json", got)
+	}
+
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	EnumPflag(fs, "format", "", "text", allowed, true, "output format")
+	if err := fs.Parse([]string{"--format", "yaml"}); err == nil {
+		t.Error("fs.Parse() should fail for value outside enum")
+	}
+	if !strings.Contains(fs.FlagUsages(), "one of: json, text") {
+		t.Errorf("FlagUsages() missing allowed values: %s", fs.FlagUsages())
+	}
+}
@@ -0,0 +1,271 @@
+package flagutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// DefaultMaxFlagFileSize is the largest flag file LoadFlagFile reads.
+const DefaultMaxFlagFileSize = 1 << 20
+
+// ErrFlagFileTooLarge is returned when a flag file exceeds DefaultMaxFlagFileSize
+var ErrFlagFileTooLarge = fmt.Errorf("flag file is too large")
+
+// flagFileEntry is one key of a flag file with its values in order.
+type flagFileEntry struct {
+	key    string
+	values []string
+	// list is set for JSON arrays, which replace slice flags as a whole.
+	list bool
+	// where locates the entry in error messages, e.g. "app.conf:3".
+	where string
+}
+
+// SetFromMap sets flags on fs from values, keyed by flag name, skipping flags
+// already set on the command line or from the environment, so call it after
+// EnvBinding.Apply for CLI > ENV > file priority. Flags it sets report
+// SourceFile from FlagSource. Unknown keys are returned as *UnknownFlagError
+// with suggestions; all problems are joined into one error.
+func SetFromMap(fs *flag.FlagSet, values map[string]string) error {
+	return setFromEntries(stdFlagSet{fs}, mapEntries(values))
+}
+
+// SetFromMapPflag is SetFromMap for a pflag.FlagSet.
+func SetFromMapPflag(fs *pflag.FlagSet, values map[string]string) error {
+	return setFromEntries(pflagFlagSet{fs}, mapEntries(values))
+}
+
+// LoadFlagFile reads flag values from path and applies them like SetFromMap.
+// A file starting with "{" is a JSON object whose values are strings,
+// numbers, booleans, arrays (one Set per element, or Replace for pflag slice
+// flags) or objects (one "key=value" Set per entry, for map flags). Other
+// files hold "name=value" lines; blank lines and lines starting with # are
+// ignored, a double-quoted value is unquoted, and repeating a name gives the
+// flag several values. The path is checked with validator.ValidatePath, and
+// only regular files of at most DefaultMaxFlagFileSize bytes are read.
+func LoadFlagFile(fs *flag.FlagSet, path string) error {
+	return loadFlagFile(stdFlagSet{fs}, path)
+}
+
+// LoadFlagFilePflag is LoadFlagFile for a pflag.FlagSet.
+func LoadFlagFilePflag(fs *pflag.FlagSet, path string) error {
+	return loadFlagFile(pflagFlagSet{fs}, path)
+}
+
+func loadFlagFile(fs flagSet, path string) error {
+	safePath, err := validator.ValidatePath(path, nil)
+	if err != nil {
+		return err
+	}
+	data, err := readRegular(safePath, DefaultMaxFlagFileSize, validator.ErrNotAFile, ErrFlagFileTooLarge)
+	if err != nil {
+		return fmt.Errorf("flag file %s: %w", path, err)
+	}
+	var entries []flagFileEntry
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		entries, err = parseJSONFlagFile(data, path)
+	} else {
+		entries, err = parseFlagFile(data, path)
+	}
+	if err != nil {
+		return err
+	}
+	return setFromEntries(fs, entries)
+}
+
+func mapEntries(values map[string]string) []flagFileEntry {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	entries := make([]flagFileEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, flagFileEntry{key: key, values: []string{values[key]}})
+	}
+	return entries
+}
+
+// parseFlagFile reads "name=value" lines.
+func parseFlagFile(data []byte, path string) ([]flagFileEntry, error) {
+	var entries []flagFileEntry
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected name=value", path, line)
+		}
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			value = unquoted
+		}
+		if i, ok := index[key]; ok {
+			entries[i].values = append(entries[i].values, value)
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, flagFileEntry{key: key, values: []string{value}, where: fmt.Sprintf("%s:%d", path, line)})
+	}
+	return entries, scanner.Err()
+}
+
+// parseJSONFlagFile reads a JSON object.
+func parseJSONFlagFile(data []byte, path string) ([]flagFileEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var entries []flagFileEntry
+	for _, key := range keys {
+		entry := flagFileEntry{key: key, where: path}
+		switch v := doc[key].(type) {
+		case []any:
+			entry.list = true
+			for _, item := range v {
+				s, err := jsonScalar(item)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+				}
+				entry.values = append(entry.values, s)
+			}
+		case map[string]any:
+			mapKeys := make([]string, 0, len(v))
+			for k := range v {
+				mapKeys = append(mapKeys, k)
+			}
+			slices.Sort(mapKeys)
+			for _, k := range mapKeys {
+				s, err := jsonScalar(v[k])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s.%s: %w", path, key, k, err)
+				}
+				entry.values = append(entry.values, k+"="+s)
+			}
+		default:
+			s, err := jsonScalar(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+			entry.values = []string{s}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// jsonScalar formats a JSON string, number or boolean as flag text.
+func jsonScalar(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// setFromEntries applies entries to flags not set on the command line or from
+// the environment and records them as SourceFile.
+func setFromEntries(fs flagSet, entries []flagFileEntry) error {
+	var errs []error
+	for _, entry := range entries {
+		f := fs.lookup(entry.key)
+		if f == nil {
+			errs = append(errs, withWhere(entry.where, &UnknownFlagError{
+				Flag:        entry.key,
+				Suggestions: flagSuggestions(fs, entry.key),
+				Err:         fmt.Errorf("unknown flag %q", entry.key),
+			}))
+			continue
+		}
+		name := canonicalFlagName(fs, f.Name)
+		if fs.changed(name) {
+			continue
+		}
+		if err := setEntry(fs, f, entry); err != nil {
+			errs = append(errs, withWhere(entry.where, err))
+			continue
+		}
+		recordSource(fs.key(), name, SourceFile)
+	}
+	return errors.Join(errs...)
+}
+
+func setEntry(fs flagSet, f *flagInfo, entry flagFileEntry) error {
+	if sv, ok := f.Value.(pflag.SliceValue); ok && entry.list {
+		if pfs, ok := fs.(pflagFlagSet); ok {
+			if err := sv.Replace(entry.values); err != nil {
+				return fmt.Errorf("invalid value %q for flag %s: %w", entry.values, f.Name, err)
+			}
+			// Replace does not record the flag as set; Set through a value
+			// that ignores its input does, the way Parse would.
+			pf := pfs.fs.Lookup(f.Name)
+			value := pf.Value
+			pf.Value = markSetValue{value}
+			defer func() { pf.Value = value }()
+			return pfs.fs.Set(f.Name, "")
+		}
+	}
+	for _, value := range entry.values {
+		err := withoutLiteralWarning(f.Value, func() error { return fs.set(f.Name, value) })
+		if err != nil {
+			return fmt.Errorf("invalid value %q for flag %s: %w", value, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// markSetValue lets pflag.FlagSet.Set mark a flag as set without changing it.
+type markSetValue struct{ pflag.Value }
+
+func (markSetValue) Set(string) error { return nil }
+
+// canonicalFlagName resolves an alias or a --no-name flag to the flag it stands for.
+func canonicalFlagName(fs flagSet, name string) string {
+	canonical := name
+	readMeta(fs.key(), func(m *setMeta) {
+		if c, ok := m.aliases[name]; ok {
+			canonical = c
+		}
+		if c, ok := m.negations[name]; ok {
+			canonical = c
+		}
+	})
+	return canonical
+}
+
+func withWhere(where string, err error) error {
+	if where == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", where, err)
+}
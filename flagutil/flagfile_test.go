@@ -0,0 +1,162 @@
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+type listValue []string
+
+func (l *listValue) String() string     { return strings.Join(*l, ",") }
+func (l *listValue) Set(s string) error { *l = append(*l, s); return nil }
+
+func writeFlagFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetFromMap(t *testing.T) {
+	t.Setenv("APP_HOST", "env-host")
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "Listen port")
+	name := fs.String("name", "", "Name")
+	host := fs.String("host", "", "Host")
+	fs.Bool("debug", false, "Debug")
+	binding := BindEnv(fs, "APP")
+	if err := fs.Parse([]string{"-name", "cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := binding.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SetFromMap(fs, map[string]string{"port": "9090", "name": "file", "host": "file-host", "prot": "1"})
+	var unknown *UnknownFlagError
+	if !errors.As(err, &unknown) || unknown.Flag != "prot" || !reflect.DeepEqual(unknown.Suggestions, []string{"--port"}) {
+		t.Fatalf("SetFromMap() error = %v, want unknown flag prot with suggestion", err)
+	}
+	if !strings.Contains(err.Error(), `unknown flag "prot" (did you mean --port?)`) {
+		t.Errorf("SetFromMap() error = %q", err)
+	}
+	if *port != 9090 || *name != "cli" || *host != "env-host" {
+		t.Errorf("port, name, host = %d, %q, %q, want 9090, cli, env-host", *port, *name, *host)
+	}
+
+	for flagName, want := range map[string]Source{"port": SourceFile, "name": SourceCLI, "host": SourceEnv, "debug": SourceDefault} {
+		if got := FlagSource(fs, flagName); got != want {
+			t.Errorf("FlagSource(%s) = %v, want %v", flagName, got, want)
+		}
+	}
+	if !HasFlag(fs, "port") {
+		t.Error("HasFlag(port) should be true for a file-sourced value")
+	}
+}
+
+func TestLoadFlagFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"key value", "app.conf", `# app settings
+port = 9090
+name="hello world"
+tag=a
+tag=b
+no-color=true
+`},
+		{"json", "app.json", `{"port": 9090, "name": "hello world", "tag": ["a", "b"], "no-color": true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			port := fs.Int("port", 8080, "Listen port")
+			name := fs.String("name", "", "Name")
+			var tags listValue
+			fs.Var(&tags, "tag", "Tags")
+			color := NegatableBool(fs, "color", true, "Color")
+			if err := LoadFlagFile(fs, writeFlagFile(t, tt.file, tt.content)); err != nil {
+				t.Fatal(err)
+			}
+			if *port != 9090 || *name != "hello world" || !reflect.DeepEqual([]string(tags), []string{"a", "b"}) || *color {
+				t.Errorf("values = %d %q %q %v", *port, *name, tags, *color)
+			}
+			if FlagSource(fs, "color") != SourceFile || FlagSource(fs, "tag") != SourceFile {
+				t.Errorf("FlagSource(color, tag) = %v, %v, want file", FlagSource(fs, "color"), FlagSource(fs, "tag"))
+			}
+		})
+	}
+}
+
+func TestLoadFlagFile_Errors(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 8080, "Listen port")
+	path := writeFlagFile(t, "bad.conf", "port=abc\nverbose=true\n")
+	err := LoadFlagFile(fs, path)
+	if err == nil || !strings.Contains(err.Error(), "bad.conf:1: invalid value \"abc\" for flag port") ||
+		!strings.Contains(err.Error(), `bad.conf:2: unknown flag "verbose"`) {
+		t.Errorf("LoadFlagFile() error = %v, want both problems with positions", err)
+	}
+	if err := LoadFlagFile(fs, writeFlagFile(t, "x.conf", "just text\n")); err == nil || !strings.Contains(err.Error(), "x.conf:1: expected name=value") {
+		t.Errorf("LoadFlagFile() syntax error = %v", err)
+	}
+	if err := LoadFlagFile(fs, writeFlagFile(t, "x.json", `{"port": {"a": [1]}}`)); err == nil {
+		t.Error("LoadFlagFile() should reject nested JSON values")
+	}
+	if err := LoadFlagFile(fs, "../etc/app.conf"); err == nil {
+		t.Error("LoadFlagFile() should reject path traversal")
+	}
+	big := writeFlagFile(t, "big.conf", strings.Repeat("#", DefaultMaxFlagFileSize+1))
+	if err := LoadFlagFile(fs, big); !errors.Is(err, ErrFlagFileTooLarge) {
+		t.Errorf("LoadFlagFile(big) error = %v, want ErrFlagFileTooLarge", err)
+	}
+	if err := LoadFlagFile(fs, t.TempDir()); !errors.Is(err, validator.ErrNotAFile) {
+		t.Errorf("LoadFlagFile(dir) error = %v, want validator.ErrNotAFile", err)
+	}
+}
+
+func TestLoadFlagFilePflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	tags := fs.StringSlice("tag", []string{"default"}, "Tags")
+	labels := fs.StringToString("label", nil, "Labels")
+	port := fs.IntP("port", "p", 8080, "Listen port")
+	if err := fs.Parse([]string{"-p", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	path := writeFlagFile(t, "app.json", `{"tag": ["a,b", "say \"hi\""], "label": {"env": "prod", "team": "core"}, "port": 2}`)
+	if err := LoadFlagFilePflag(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*tags, []string{"a,b", `say "hi"`}) {
+		t.Errorf("tag = %q, want elements kept whole", *tags)
+	}
+	visited := false
+	fs.Visit(func(f *pflag.Flag) { visited = visited || f.Name == "tag" })
+	if !visited || !fs.Changed("tag") {
+		t.Error("Visit() should include a list set from the file")
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"env": "prod", "team": "core"}) {
+		t.Errorf("label = %v", *labels)
+	}
+	if *port != 1 || FlagSourcePflag(fs, "port") != SourceCLI {
+		t.Errorf("port = %d (%v), want the CLI value", *port, FlagSourcePflag(fs, "port"))
+	}
+	if !HasFlagPflag(fs, "tag") || FlagSourcePflag(fs, "tag") != SourceFile || FlagSourcePflag(fs, "missing") != SourceDefault {
+		t.Errorf("FlagSourcePflag(tag) = %v", FlagSourcePflag(fs, "tag"))
+	}
+	if err := SetFromMapPflag(fs, map[string]string{"port": "3"}); err != nil || *port != 1 {
+		t.Errorf("SetFromMapPflag() = %v, port = %d, want CLI value kept", err, *port)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/validator"
+)
+
+func TestLoadFlagFile_FIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo.conf")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- LoadFlagFile(flag.NewFlagSet("app", flag.ContinueOnError), path)
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, validator.ErrNotAFile) {
+			t.Errorf("LoadFlagFile(fifo) error = %v, want validator.ErrNotAFile", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("LoadFlagFile(fifo) blocked opening the FIFO")
+	}
+}
@@ -0,0 +1,237 @@
+package flagutil
+
+import (
+	"flag"
+	"io"
+	"runtime"
+	"sync"
+	"weak"
+
+	"github.com/spf13/pflag"
+)
+
+// flagInfo is the common view of a *flag.Flag or *pflag.Flag used by helpers
+// that support both FlagSet types.
+type flagInfo struct {
+	Name      string
+	Shorthand string
+	// Usage is the usage string as defined, including back quotes.
+	Usage string
+	// ArgName and PlainUsage are the results of UnquoteUsage.
+	ArgName    string
+	PlainUsage string
+	DefValue   string
+	Value      flag.Value
+	IsBool     bool
+	Hidden     bool
+	Deprecated string
+}
+
+// flagSet abstracts over *flag.FlagSet and *pflag.FlagSet.
+type flagSet interface {
+	// key identifies the underlying FlagSet in the metadata registry.
+	key() any
+	name() string
+	output() io.Writer
+	// all returns every defined flag in lexicographical order.
+	all() []*flagInfo
+	lookup(name string) *flagInfo
+	changed(name string) bool
+	set(name, value string) error
+	setUsage(name, usage string)
+}
+
+type stdFlagSet struct{ fs *flag.FlagSet }
+
+func (s stdFlagSet) key() any          { return s.fs }
+func (s stdFlagSet) name() string      { return s.fs.Name() }
+func (s stdFlagSet) output() io.Writer { return s.fs.Output() }
+
+func (s stdFlagSet) all() []*flagInfo {
+	var infos []*flagInfo
+	s.fs.VisitAll(func(f *flag.Flag) {
+		infos = append(infos, s.info(f))
+	})
+	return infos
+}
+
+func (s stdFlagSet) lookup(name string) *flagInfo {
+	if f := s.fs.Lookup(name); f != nil {
+		return s.info(f)
+	}
+	return nil
+}
+
+// info adds the deprecation recorded by Deprecate and the shorthand recorded
+// by Shorthands to stdFlagInfo.
+func (s stdFlagSet) info(f *flag.Flag) *flagInfo {
+	info := stdFlagInfo(f)
+	readMeta(s.fs, func(m *setMeta) {
+		info.Deprecated = m.deprecated[f.Name]
+		info.Shorthand = m.shorthands[f.Name]
+	})
+	return info
+}
+
+func (s stdFlagSet) changed(name string) bool     { return HasFlag(s.fs, name) }
+func (s stdFlagSet) set(name, value string) error { return s.fs.Set(name, value) }
+func (s stdFlagSet) setUsage(name, usage string) {
+	if f := s.fs.Lookup(name); f != nil {
+		f.Usage = usage
+	}
+}
+
+func stdFlagInfo(f *flag.Flag) *flagInfo {
+	argName, plainUsage := flag.UnquoteUsage(f)
+	return &flagInfo{
+		Name:       f.Name,
+		Usage:      f.Usage,
+		ArgName:    argName,
+		PlainUsage: plainUsage,
+		DefValue:   f.DefValue,
+		Value:      f.Value,
+		IsBool:     isBoolValue(f.Value),
+	}
+}
+
+type pflagFlagSet struct{ fs *pflag.FlagSet }
+
+func (s pflagFlagSet) key() any          { return s.fs }
+func (s pflagFlagSet) name() string      { return s.fs.Name() }
+func (s pflagFlagSet) output() io.Writer { return s.fs.Output() }
+
+func (s pflagFlagSet) all() []*flagInfo {
+	var infos []*flagInfo
+	s.fs.VisitAll(func(f *pflag.Flag) {
+		infos = append(infos, pflagFlagInfo(f))
+	})
+	return infos
+}
+
+func (s pflagFlagSet) lookup(name string) *flagInfo {
+	if f := s.fs.Lookup(name); f != nil {
+		return pflagFlagInfo(f)
+	}
+	return nil
+}
+
+func (s pflagFlagSet) changed(name string) bool     { return HasFlagPflag(s.fs, name) }
+func (s pflagFlagSet) set(name, value string) error { return s.fs.Set(name, value) }
+func (s pflagFlagSet) setUsage(name, usage string) {
+	if f := s.fs.Lookup(name); f != nil {
+		f.Usage = usage
+	}
+}
+
+func pflagFlagInfo(f *pflag.Flag) *flagInfo {
+	argName, plainUsage := pflag.UnquoteUsage(f)
+	return &flagInfo{
+		Name:       f.Name,
+		Shorthand:  f.Shorthand,
+		Usage:      f.Usage,
+		ArgName:    argName,
+		PlainUsage: plainUsage,
+		DefValue:   f.DefValue,
+		Value:      f.Value,
+		IsBool:     f.NoOptDefVal != "",
+		Hidden:     f.Hidden,
+		Deprecated: f.Deprecated,
+	}
+}
+
+// setMeta holds metadata flagutil keeps for a FlagSet beyond what the
+// flag packages store themselves.
+type setMeta struct {
+	// envKeys maps flag names to their bound environment variables.
+	envKeys map[string]string
+	// sources records flags set from the environment or a file; flags set
+	// without a record came from the command line.
+	sources map[string]Source
+	// hidden records flags omitted from generated help.
+	hidden map[string]bool
+	// groups maps flag names to help groups; groupOrder keeps first-use order.
+	groups     map[string]string
+	groupOrder []string
+	// completions holds value completion settings for flags.
+	completions map[string]completion
+	// aliases maps alias names to canonical flag names.
+	aliases map[string]string
+	// deprecated maps deprecated flag names to their messages.
+	deprecated map[string]string
+	// shorthands maps flag names to shorthands registered with Shorthands.
+	shorthands map[string]string
+	// negations maps --no-name flags defined by NegatableBool to name.
+	negations map[string]string
+	// constraints holds the rules checked by CheckConstraints, in registration order.
+	constraints []constraint
+}
+
+// registry associates metadata with FlagSets. It holds them weakly, so the
+// metadata of a FlagSet is dropped once the FlagSet is garbage collected, as
+// happens with the sets of tests and subcommands. Metadata must therefore not
+// refer back to its FlagSet.
+var registry = struct {
+	sync.Mutex
+	sets map[any]*setMeta
+}{sets: make(map[any]*setMeta)}
+
+// weakKey returns the registry key for a *flag.FlagSet or *pflag.FlagSet.
+func weakKey(key any) any {
+	switch k := key.(type) {
+	case *flag.FlagSet:
+		return weak.Make(k)
+	case *pflag.FlagSet:
+		return weak.Make(k)
+	}
+	return key
+}
+
+// releaseWhenCollected removes the metadata of key once it is collected.
+func releaseWhenCollected(key, wk any) {
+	release := func(wk any) {
+		registry.Lock()
+		defer registry.Unlock()
+		delete(registry.sets, wk)
+	}
+	switch k := key.(type) {
+	case *flag.FlagSet:
+		runtime.AddCleanup(k, release, wk)
+	case *pflag.FlagSet:
+		runtime.AddCleanup(k, release, wk)
+	}
+}
+
+// updateMeta runs fn with the metadata for key, creating it if needed.
+func updateMeta(key any, fn func(m *setMeta)) {
+	wk := weakKey(key)
+	registry.Lock()
+	defer registry.Unlock()
+	m, ok := registry.sets[wk]
+	if !ok {
+		m = &setMeta{
+			envKeys: make(map[string]string),
+			sources: make(map[string]Source),
+			hidden:  make(map[string]bool),
+			groups:  make(map[string]string),
+
+			completions: make(map[string]completion),
+			aliases:     make(map[string]string),
+			deprecated:  make(map[string]string),
+			shorthands:  make(map[string]string),
+			negations:   make(map[string]string),
+		}
+		registry.sets[wk] = m
+		releaseWhenCollected(key, wk)
+	}
+	fn(m)
+}
+
+// readMeta runs fn with the metadata for key if any exists.
+func readMeta(key any, fn func(m *setMeta)) {
+	wk := weakKey(key)
+	registry.Lock()
+	defer registry.Unlock()
+	if m, ok := registry.sets[wk]; ok {
+		fn(m)
+	}
+}
@@ -3,49 +3,66 @@ package flagutil
 import (
 	"flag"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
 )
 
-// HasFlag checks if a command-line flag is set in the given FlagSet
+// HasFlag checks if a command-line flag is set in the given FlagSet.
+// Setting an alias (see Alias) counts as setting its canonical flag.
+// Flags set by EnvBinding.Apply, SetFromMap or LoadFlagFile count as set too;
+// FlagSource reports whether the value came from the command line.
 func HasFlag(fs *flag.FlagSet, name string) bool {
+	names := flagNames(fs, name)
 	found := false
 	fs.Visit(func(f *flag.Flag) {
-		if f.Name == name {
+		if slices.Contains(names, f.Name) {
 			found = true
 		}
 	})
 	return found
 }
 
-// HasFlagInArgs checks if a flag is present in args (supports -name, --name, -name=value, --name=value)
+// HasFlagInArgs checks if a flag is present in args (supports -name, --name, -name=value, --name=value).
+// Arguments after a "--" terminator are ignored. Without flag definitions every
+// flag is assumed not to take a separate value, so a value that looks like a
+// flag is misclassified: HasFlagInArgs([]string{"--name", "--port"}, "port")
+// is true even when --name takes a value. Use HasFlagInArgsWithSpec with
+// SpecFromFlagSet or SpecFromPflag to tell flags apart from flag values.
 func HasFlagInArgs(args []string, name string) bool {
+	return HasFlagInArgsWithSpec(args, name, nil)
+}
+
+// HasFlagInArgsWithSpec checks if a flag is present in args, using spec to know which
+// flags take values and which shorthands exist. name may be a long name or a shorthand.
+// Build spec with SpecFromFlagSet or SpecFromPflag.
+func HasFlagInArgsWithSpec(args []string, name string, spec *ArgSpec) bool {
 	if name == "" {
 		return false
 	}
-
-	longForm := "--" + name
-	shortForm := "-" + name
-	longPrefix := longForm + "="
-	shortPrefix := shortForm + "="
-
-	for _, arg := range args {
-		if arg == longForm || arg == shortForm || strings.HasPrefix(arg, longPrefix) || strings.HasPrefix(arg, shortPrefix) {
-			return true
-		}
-	}
-
-	return false
+	return hasFlagToken(TokenizeArgs(args, spec), spec.canonical(name))
 }
 
-// HasFlagInOSArgs checks if a flag is present in os.Args
+// HasFlagInOSArgs checks if a flag is present in os.Args. When flag.CommandLine
+// defines name, its definitions are used to tell flags apart from flag values
+// (see HasFlagInArgsWithSpec); otherwise it behaves like HasFlagInArgs. For
+// pflag or other flag sets, use HasFlagInOSArgsWithSpec.
 func HasFlagInOSArgs(name string) bool {
+	if name != "" && flag.CommandLine.Lookup(name) != nil {
+		return HasFlagInArgsWithSpec(os.Args[1:], name, SpecFromFlagSet(flag.CommandLine))
+	}
 	return HasFlagInArgs(os.Args[1:], name)
 }
 
+// HasFlagInOSArgsWithSpec checks if a flag is present in os.Args using spec.
+func HasFlagInOSArgsWithSpec(name string, spec *ArgSpec) bool {
+	return HasFlagInArgsWithSpec(os.Args[1:], name, spec)
+}
+
 // GetFlagValue returns the string value for a flag if it was set.
 func GetFlagValue(fs *flag.FlagSet, name string) (string, bool) {
 	if fs == nil || name == "" {
@@ -155,9 +172,27 @@ func GetDuration(fs *flag.FlagSet, name string, defaultValue time.Duration) time
 	return defaultValue
 }
 
+// GetStringSlice returns the elements of a list flag, or defaultValue when not set.
+// Values implementing pflag.SliceValue (such as IPSlice and PrefixSlice) are
+// read element by element; other values are returned as a single element.
+func GetStringSlice(fs *flag.FlagSet, name string, defaultValue []string) []string {
+	value, ok := GetFlagValue(fs, name)
+	if !ok {
+		return defaultValue
+	}
+	if sv, ok := fs.Lookup(name).Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+	if value == "" {
+		return defaultValue
+	}
+	return []string{value}
+}
+
 // ReadPasswordFromFile reads password from file (security improvement).
 // Path is validated with path traversal check; relative paths are resolved to absolute.
 // File content is trimmed of leading and trailing whitespace.
+// See ReadPasswordFromFileWithOptions for size, file type and permission checks.
 func ReadPasswordFromFile(filePath string) (string, error) {
 	// Security: reject path traversal and resolve to absolute path
 	safePath, err := validator.ValidatePath(filePath, &validator.PathOptions{CheckTraversal: true})
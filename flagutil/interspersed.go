@@ -0,0 +1,109 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Shorthands defines one-letter shorthands for flags on fs, e.g.
+// {"v": "verbose", "p": "port"}. Each letter becomes an Alias of its flag,
+// so -v works with fs.Parse too, and help shows "-v, --verbose".
+// ParseInterspersed also accepts bundles of boolean shorthands such as -vq.
+// It panics if a key is not a single character or a flag is not defined.
+func Shorthands(fs *flag.FlagSet, shorthands map[string]string) {
+	for letter, name := range shorthands {
+		if len(letter) != 1 {
+			panic(fmt.Sprintf("flagutil: shorthand %q for %q must be a single character", letter, name))
+		}
+		Alias(fs, letter, name)
+		updateMeta(fs, func(m *setMeta) { m.shorthands[name] = letter })
+	}
+}
+
+// shorthandOf returns the shorthand registered for flag name, if any.
+func shorthandOf(key any, name string) string {
+	var letter string
+	readMeta(key, func(m *setMeta) { letter = m.shorthands[name] })
+	return letter
+}
+
+// ParseInterspersed parses args like Parse, but GNU style: flags may follow
+// positional arguments, "--" ends flag parsing, and a single-dash argument
+// that is not a defined flag is read as a bundle of shorthands registered
+// with Shorthands (-vq is -v -q; the last one may take a value, as in -vp80
+// or -vp 80). fs.Args() holds the positionals in their original order.
+// Errors are reported as fs.Parse reports them and fs.ErrorHandling() is
+// honoured.
+func ParseInterspersed(fs *flag.FlagSet, args []string) error {
+	var flags, positionals []string
+	missingValue := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positionals = append(positionals, args[i+1:]...)
+			i = len(args)
+		case len(arg) < 2 || arg[0] != '-':
+			positionals = append(positionals, arg)
+		default:
+			expanded, needsValue := expandFlagArg(fs, arg)
+			flags = append(flags, expanded...)
+			if needsValue && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			} else if needsValue {
+				missingValue = true
+			}
+		}
+	}
+	// A trailing flag without its value must stay last so fs.Parse reports it.
+	if len(positionals) > 0 && !missingValue {
+		flags = append(append(flags, "--"), positionals...)
+	}
+	return Parse(fs, flags)
+}
+
+// expandFlagArg rewrites one flag argument into arguments fs.Parse accepts
+// and reports whether the last of them needs the next argument as its value.
+func expandFlagArg(fs *flag.FlagSet, arg string) ([]string, bool) {
+	body := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	name, _, hasValue := strings.Cut(body, "=")
+	if f := fs.Lookup(name); f != nil || strings.HasPrefix(arg, "--") || len(body) < 2 || !isShorthandBundle(fs, body) {
+		return []string{arg}, f != nil && !hasValue && !isBoolValue(f.Value)
+	}
+
+	var expanded []string
+	for j := 0; j < len(body); j++ {
+		f := fs.Lookup(body[j : j+1])
+		rest := body[j+1:]
+		if value, ok := strings.CutPrefix(rest, "="); ok {
+			return append(expanded, "-"+f.Name+"="+value), false
+		}
+		if !isBoolValue(f.Value) {
+			if rest != "" {
+				return append(expanded, "-"+f.Name+"="+rest), false
+			}
+			return append(expanded, "-"+f.Name), true
+		}
+		expanded = append(expanded, "-"+f.Name)
+	}
+	return expanded, false
+}
+
+// isShorthandBundle reports whether body starts with registered shorthands or
+// one-letter Count flags up to the first one that takes a value, e.g. "vq",
+// "vvv" or "vp80".
+func isShorthandBundle(fs *flag.FlagSet, body string) bool {
+	for j := 0; j < len(body); j++ {
+		letter := body[j : j+1]
+		f := fs.Lookup(letter)
+		if f == nil || (shorthandOf(fs, flagNames(fs, letter)[0]) != letter && !isCountValue(f.Value)) {
+			return false
+		}
+		if !isBoolValue(f.Value) || strings.HasPrefix(body[j+1:], "=") {
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newInterspersedFlagSet() (*flag.FlagSet, *bool, *bool, *int, *string) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+	verbose := fs.Bool("verbose", false, "Verbose output")
+	quiet := fs.Bool("quiet", false, "Quiet output")
+	port := fs.Int("port", 8080, "Listen port")
+	name := fs.String("name", "", "Name")
+	Shorthands(fs, map[string]string{"v": "verbose", "q": "quiet", "p": "port"})
+	return fs, verbose, quiet, port, name
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		verbose  bool
+		quiet    bool
+		port     int
+		flagName string
+	}{
+		{"flags after positional", []string{"file.txt", "--verbose", "-name", "x"}, []string{"file.txt"}, true, false, 8080, "x"},
+		{"terminator", []string{"a", "--", "--verbose", "-q"}, []string{"a", "--verbose", "-q"}, false, false, 8080, ""},
+		{"bundle", []string{"-vq", "a", "b"}, []string{"a", "b"}, true, true, 8080, ""},
+		{"bundle inline value", []string{"a", "-vp80"}, []string{"a"}, true, false, 80, ""},
+		{"bundle separate value", []string{"-vp", "81", "a"}, []string{"a"}, true, false, 81, ""},
+		{"bundle equals value", []string{"-qp=82"}, nil, false, true, 82, ""},
+		{"value looks like a flag", []string{"--name", "-v", "a"}, []string{"a"}, false, false, 8080, "-v"},
+		{"single dash long", []string{"a", "-port=90", "-v"}, []string{"a"}, true, false, 90, ""},
+		{"stdin dash", []string{"-", "-q"}, []string{"-"}, false, true, 8080, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, verbose, quiet, port, name := newInterspersedFlagSet()
+			if err := ParseInterspersed(fs, tt.args); err != nil {
+				t.Fatalf("ParseInterspersed(%q) failed: %v", tt.args, err)
+			}
+			if got := fs.Args(); !reflect.DeepEqual(got, tt.wantArgs) && (len(got) != 0 || len(tt.wantArgs) != 0) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+			if *verbose != tt.verbose || *quiet != tt.quiet || *port != tt.port || *name != tt.flagName {
+				t.Errorf("values = %v %v %d %q, want %v %v %d %q",
+					*verbose, *quiet, *port, *name, tt.verbose, tt.quiet, tt.port, tt.flagName)
+			}
+		})
+	}
+}
+
+func TestParseInterspersed_Errors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"a", "-port"}, "flag needs an argument: -port"},
+		{[]string{"a", "-port", "x"}, `invalid value "x" for flag -port: parse error`},
+		{[]string{"a", "-vx"}, "flag provided but not defined: -vx (did you mean --verbose?)"},
+		{[]string{"a", "--verbos"}, "flag provided but not defined: -verbos (did you mean --verbose?)"},
+	}
+	for _, tt := range tests {
+		fs, _, _, _, _ := newInterspersedFlagSet()
+		out := fs.Output().(*bytes.Buffer)
+		err := ParseInterspersed(fs, tt.args)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseInterspersed(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+		if !strings.HasPrefix(out.String(), tt.want+"\n") {
+			t.Errorf("ParseInterspersed(%q) output = %q, want the error and usage", tt.args, out.String())
+		}
+	}
+}
+
+func TestShorthands_Help(t *testing.T) {
+	fs, _, _, _, _ := newInterspersedFlagSet()
+	var help bytes.Buffer
+	if err := NewUsage(fs).WriteText(&help); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"-v, --verbose", "-p, --port int", "    --name string"} {
+		if !strings.Contains(help.String(), want) {
+			t.Errorf("WriteText() missing %q:\n%s", want, help.String())
+		}
+	}
+	if strings.Contains(help.String(), "alias for") {
+		t.Errorf("WriteText() should not list shorthands separately:\n%s", help.String())
+	}
+	if err := fs.Parse([]string{"-v"}); err != nil || !HasFlag(fs, "verbose") {
+		t.Errorf("fs.Parse(-v) = %v, HasFlag(verbose) = %v", err, HasFlag(fs, "verbose"))
+	}
+	if !HasFlagInArgsWithSpec([]string{"-p", "1", "x"}, "port", SpecFromFlagSet(fs)) {
+		t.Error("SpecFromFlagSet() should know registered shorthands")
+	}
+}
@@ -0,0 +1,86 @@
+package flagutil
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// negatedBool is the value of a --no-name flag: setting it to true sets the
+// shared boolean to false.
+type negatedBool struct {
+	p *bool
+}
+
+func (n *negatedBool) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*n.p = !b
+	return nil
+}
+
+func (n *negatedBool) String() string {
+	if n.p == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!*n.p)
+}
+
+// IsBoolFlag lets --no-name be given without a value.
+func (n *negatedBool) IsBoolFlag() bool { return true }
+
+// Type implements pflag.Value.
+func (n *negatedBool) Type() string { return "bool" }
+
+// NegatableBool defines a boolean flag name together with --no-name, which
+// sets it to false, and returns a pointer to the value. When --no-name is
+// given, HasFlag(fs, name) reports name as set and GetBool returns false, so
+// configutil.ResolveBool treats it as a CLI override of the environment.
+// Help shows the pair as --[no-]name.
+func NegatableBool(fs *flag.FlagSet, name string, value bool, usage string) *bool {
+	p := fs.Bool(name, value, usage)
+	fs.Var(&negatedBool{p: p}, negatedName(name), "disable --"+name)
+	markNegation(fs, name)
+	return p
+}
+
+// NegatableBoolPflag is NegatableBool for a pflag.FlagSet.
+func NegatableBoolPflag(fs *pflag.FlagSet, name, shorthand string, value bool, usage string) *bool {
+	p := fs.BoolP(name, shorthand, value, usage)
+	fs.Var(&negatedBool{p: p}, negatedName(name), "disable --"+name)
+	fs.Lookup(negatedName(name)).NoOptDefVal = "true"
+	markNegation(fs, name)
+	return p
+}
+
+func negatedName(name string) string {
+	return "no-" + name
+}
+
+func markNegation(key any, name string) {
+	updateMeta(key, func(m *setMeta) {
+		m.negations[negatedName(name)] = name
+		m.hidden[negatedName(name)] = true
+	})
+}
+
+// negationOf returns the --no-name flag registered for name, if any.
+func negationOf(key any, name string) string {
+	var negation string
+	readMeta(key, func(m *setMeta) {
+		if _, ok := m.negations[negatedName(name)]; ok {
+			negation = negatedName(name)
+		}
+	})
+	return negation
+}
+
+// isNegation reports whether name is a --no-name flag defined by NegatableBool.
+func isNegation(key any, name string) bool {
+	var ok bool
+	readMeta(key, func(m *setMeta) { _, ok = m.negations[name] })
+	return ok
+}
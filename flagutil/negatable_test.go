@@ -0,0 +1,69 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestNegatableBool(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    bool
+		wantSet bool
+	}{
+		{nil, true, false},
+		{[]string{"--no-color"}, false, true},
+		{[]string{"--color=false", "--no-color=false"}, true, true},
+		{[]string{"--no-color", "--color"}, true, true},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		color := NegatableBool(fs, "color", true, "Colorize output")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if *color != tt.want || HasFlag(fs, "color") != tt.wantSet {
+			t.Errorf("args %q: color = %v, HasFlag = %v, want %v, %v", tt.args, *color, HasFlag(fs, "color"), tt.want, tt.wantSet)
+		}
+		if tt.wantSet && GetBool(fs, "color", !tt.want) != tt.want {
+			t.Errorf("args %q: GetBool() = %v, want %v", tt.args, !tt.want, tt.want)
+		}
+	}
+}
+
+func TestNegatableBoolPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	color := NegatableBoolPflag(fs, "color", "c", true, "Colorize output")
+	if HasFlagPflag(fs, "color") {
+		t.Error("HasFlagPflag() before parsing should be false")
+	}
+	if err := fs.Parse([]string{"--no-color"}); err != nil {
+		t.Fatal(err)
+	}
+	if *color || !HasFlagPflag(fs, "color") || GetBoolPflag(fs, "color", true) {
+		t.Errorf("color = %v, HasFlagPflag = %v, GetBoolPflag = %v", *color, HasFlagPflag(fs, "color"), GetBoolPflag(fs, "color", true))
+	}
+}
+
+func TestNegatableBool_Help(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	NegatableBool(fs, "color", true, "Colorize output")
+	b := BindEnv(fs, "APP")
+	if b.EnvKey("no-color") != "" {
+		t.Errorf("BindEnv() should skip --no-color, got %q", b.EnvKey("no-color"))
+	}
+	var help bytes.Buffer
+	if err := NewUsage(fs).WriteText(&help); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(help.String(), "--[no-]color    Colorize output (default true) [env: APP_COLOR]") {
+		t.Errorf("WriteText() =\n%s", help.String())
+	}
+	if strings.Contains(help.String(), "disable") {
+		t.Errorf("WriteText() should not list --no-color separately:\n%s", help.String())
+	}
+}
@@ -0,0 +1,467 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+// ipValue is a netip.Addr flag value; the zero Addr means "not set".
+type ipValue netip.Addr
+
+func (v *ipValue) Set(s string) error {
+	addr, err := ParseIP(s)
+	if err != nil {
+		return err
+	}
+	*v = ipValue(addr)
+	return nil
+}
+
+func (v *ipValue) String() string { return addrString(netip.Addr(*v)) }
+
+func (v *ipValue) Type() string { return "ip" }
+
+// Get implements flag.Getter.
+func (v *ipValue) Get() any { return netip.Addr(*v) }
+
+// prefixValue is a netip.Prefix flag value; the zero Prefix means "not set".
+type prefixValue netip.Prefix
+
+func (v *prefixValue) Set(s string) error {
+	prefix, err := ParsePrefix(s)
+	if err != nil {
+		return err
+	}
+	*v = prefixValue(prefix)
+	return nil
+}
+
+func (v *prefixValue) String() string { return prefixString(netip.Prefix(*v)) }
+
+func (v *prefixValue) Type() string { return "cidr" }
+
+// Get implements flag.Getter.
+func (v *prefixValue) Get() any { return netip.Prefix(*v) }
+
+// listenAddrValue is a listen address flag value such as ":8080".
+type listenAddrValue string
+
+func (v *listenAddrValue) Set(s string) error {
+	addr, err := ParseListenAddr(s)
+	if err != nil {
+		return err
+	}
+	*v = listenAddrValue(addr)
+	return nil
+}
+
+func (v *listenAddrValue) String() string { return string(*v) }
+
+func (v *listenAddrValue) Type() string { return "addr" }
+
+// Get implements flag.Getter.
+func (v *listenAddrValue) Get() any { return string(*v) }
+
+// ParseIP parses an IPv4 or IPv6 address, with an optional IPv6 zone.
+func ParseIP(s string) (netip.Addr, error) {
+	return netip.ParseAddr(strings.TrimSpace(s))
+}
+
+// ParsePrefix parses a CIDR prefix such as "10.0.0.0/8" or "fd00::/8".
+// A bare address is accepted as a single-address prefix (/32 or /128).
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
+
+// ParseListenAddr validates an address to listen on and returns it in the
+// form net.Listen expects. Unlike validator.ValidateHostPort the host may be
+// empty (":8080" listens on all interfaces), and a bare port such as "8080"
+// is read as ":8080". IPv6 hosts must be bracketed, as in "[::]:443".
+func ParseListenAddr(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && !strings.Contains(s, ":") {
+		s = ":" + s
+	}
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", validator.ErrInvalidHostPort, err)
+	}
+	if host == "" {
+		if _, err := validator.ValidatePortString(portStr); err != nil {
+			return "", fmt.Errorf("%w: %w", validator.ErrInvalidHostPort, err)
+		}
+		return net.JoinHostPort(host, portStr), nil
+	}
+	host, port, err := validator.ValidateHostPort(s)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, fmt.Sprint(port)), nil
+}
+
+// IP defines a flag holding an IP address and returns a pointer to it.
+// Set rejects anything netip.ParseAddr does not accept; the zero Addr
+// means the flag was not given and has no default.
+func IP(fs *flag.FlagSet, name string, value netip.Addr, usage string) *netip.Addr {
+	p := new(netip.Addr)
+	*p = value
+	fs.Var((*ipValue)(p), name, usage)
+	return p
+}
+
+// IPPflag is IP for a pflag.FlagSet; shorthand may be empty.
+func IPPflag(fs *pflag.FlagSet, name, shorthand string, value netip.Addr, usage string) *netip.Addr {
+	p := new(netip.Addr)
+	*p = value
+	fs.VarP((*ipValue)(p), name, shorthand, usage)
+	return p
+}
+
+// Prefix defines a flag holding a CIDR prefix and returns a pointer to it.
+// Set accepts what ParsePrefix accepts.
+func Prefix(fs *flag.FlagSet, name string, value netip.Prefix, usage string) *netip.Prefix {
+	p := new(netip.Prefix)
+	*p = value
+	fs.Var((*prefixValue)(p), name, usage)
+	return p
+}
+
+// PrefixPflag is Prefix for a pflag.FlagSet; shorthand may be empty.
+func PrefixPflag(fs *pflag.FlagSet, name, shorthand string, value netip.Prefix, usage string) *netip.Prefix {
+	p := new(netip.Prefix)
+	*p = value
+	fs.VarP((*prefixValue)(p), name, shorthand, usage)
+	return p
+}
+
+// ListenAddr defines a flag holding an address to listen on, validated with
+// ParseListenAddr, and returns a pointer to it. It panics if value is not
+// empty and not a valid listen address.
+func ListenAddr(fs *flag.FlagSet, name, value, usage string) *string {
+	p := mustListenAddr(name, value)
+	fs.Var((*listenAddrValue)(p), name, usage)
+	return p
+}
+
+// ListenAddrPflag is ListenAddr for a pflag.FlagSet; shorthand may be empty.
+func ListenAddrPflag(fs *pflag.FlagSet, name, shorthand, value, usage string) *string {
+	p := mustListenAddr(name, value)
+	fs.VarP((*listenAddrValue)(p), name, shorthand, usage)
+	return p
+}
+
+func mustListenAddr(name, value string) *string {
+	p := new(string)
+	if value != "" {
+		addr, err := ParseListenAddr(value)
+		if err != nil {
+			panic(fmt.Sprintf("flagutil: listen address flag %q: %v", name, err))
+		}
+		*p = addr
+	}
+	return p
+}
+
+// HostPortValue is a flag value holding a host:port address validated with
+// validator.ValidateHostPort in Set. It implements flag.Value, flag.Getter
+// and pflag.Value.
+type HostPortValue struct {
+	host string
+	port int
+}
+
+// NewHostPortValue creates a HostPortValue with the given default; an empty
+// default means "no value".
+func NewHostPortValue(defaultValue string) (*HostPortValue, error) {
+	v := &HostPortValue{}
+	if defaultValue != "" {
+		if err := v.Set(defaultValue); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Set validates and stores a host:port address.
+func (v *HostPortValue) Set(s string) error {
+	host, port, err := validator.ValidateHostPort(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	v.host, v.port = host, port
+	return nil
+}
+
+// String returns the address as "host:port", or "" when unset.
+func (v *HostPortValue) String() string {
+	if v == nil || v.host == "" {
+		return ""
+	}
+	return net.JoinHostPort(v.host, fmt.Sprint(v.port))
+}
+
+// Type implements pflag.Value.
+func (v *HostPortValue) Type() string { return "hostPort" }
+
+// Get implements flag.Getter and returns the same as String.
+func (v *HostPortValue) Get() any { return v.String() }
+
+// Host returns the host part, without brackets for IPv6 addresses.
+func (v *HostPortValue) Host() string { return v.host }
+
+// Port returns the port number, or 0 when unset.
+func (v *HostPortValue) Port() int { return v.port }
+
+// HostPort defines a host:port flag and returns its value.
+// It panics if value is not empty and not a valid host:port.
+func HostPort(fs *flag.FlagSet, name, value, usage string) *HostPortValue {
+	v := mustHostPortValue(name, value)
+	fs.Var(v, name, usage)
+	return v
+}
+
+// HostPortPflag is HostPort for a pflag.FlagSet; shorthand may be empty.
+func HostPortPflag(fs *pflag.FlagSet, name, shorthand, value, usage string) *HostPortValue {
+	v := mustHostPortValue(name, value)
+	fs.VarP(v, name, shorthand, usage)
+	return v
+}
+
+func mustHostPortValue(name, value string) *HostPortValue {
+	v, err := NewHostPortValue(value)
+	if err != nil {
+		panic(fmt.Sprintf("flagutil: host:port flag %q: %v", name, err))
+	}
+	return v
+}
+
+// URLValue is a flag value holding a URL validated with validator.ValidateURL
+// in Set, so the SSRF checks (scheme allowlist, localhost and private address
+// blocking, optional DNS resolution) apply to command-line input. It
+// implements flag.Value, flag.Getter and pflag.Value.
+type URLValue struct {
+	url  *url.URL
+	opts *validator.URLOptions
+}
+
+// NewURLValue creates a URLValue validating with opts (nil uses the secure
+// defaults of validator.ValidateURL). The default is validated like any other
+// value, as configutil.ResolveURL does, so a default such as
+// "http://localhost:8080" needs opts with AllowLocalhost. An empty default
+// means "no value".
+func NewURLValue(defaultValue string, opts *validator.URLOptions) (*URLValue, error) {
+	v := &URLValue{opts: opts}
+	if defaultValue != "" {
+		if err := v.Set(defaultValue); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Set validates and stores a URL.
+func (v *URLValue) Set(s string) error {
+	s = strings.TrimSpace(s)
+	if err := validator.ValidateURL(s, v.opts); err != nil {
+		return err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	v.url = u
+	return nil
+}
+
+// String returns the URL, or "" when unset.
+func (v *URLValue) String() string {
+	if v == nil || v.url == nil {
+		return ""
+	}
+	return v.url.String()
+}
+
+// Type implements pflag.Value.
+func (v *URLValue) Type() string { return "url" }
+
+// Get implements flag.Getter and returns the *url.URL.
+func (v *URLValue) Get() any { return v.url }
+
+// URL returns the parsed URL, or nil when unset.
+func (v *URLValue) URL() *url.URL { return v.url }
+
+// URL defines a URL flag validated with opts and returns its value.
+// It panics if value is not empty and fails validation.
+func URL(fs *flag.FlagSet, name, value string, opts *validator.URLOptions, usage string) *URLValue {
+	v := mustURLValue(name, value, opts)
+	fs.Var(v, name, usage)
+	return v
+}
+
+// URLPflag is URL for a pflag.FlagSet; shorthand may be empty.
+func URLPflag(fs *pflag.FlagSet, name, shorthand, value string, opts *validator.URLOptions, usage string) *URLValue {
+	v := mustURLValue(name, value, opts)
+	fs.VarP(v, name, shorthand, usage)
+	return v
+}
+
+func mustURLValue(name, value string, opts *validator.URLOptions) *URLValue {
+	v, err := NewURLValue(value, opts)
+	if err != nil {
+		panic(fmt.Sprintf("flagutil: URL flag %q: %v", name, err))
+	}
+	return v
+}
+
+// sliceValue is a comma-separated list flag. The first Set replaces the
+// default and later ones append, as pflag slice flags do. It implements
+// pflag.SliceValue, so LoadFlagFile and GetStringSlicePflag read it natively.
+type sliceValue[T fmt.Stringer] struct {
+	p       *[]T
+	parse   func(string) (T, error)
+	typ     string
+	changed bool
+}
+
+func (v *sliceValue[T]) Set(s string) error {
+	var items []T
+	for _, part := range strings.Split(s, ",") {
+		item, err := v.parse(part)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if v.changed {
+		*v.p = append(*v.p, items...)
+	} else {
+		*v.p = items
+	}
+	v.changed = true
+	return nil
+}
+
+func (v *sliceValue[T]) String() string {
+	if v == nil || v.p == nil {
+		return "[]"
+	}
+	return "[" + strings.Join(v.GetSlice(), ",") + "]"
+}
+
+func (v *sliceValue[T]) Type() string { return v.typ }
+
+// Get implements flag.Getter.
+func (v *sliceValue[T]) Get() any { return *v.p }
+
+// Append implements pflag.SliceValue.
+func (v *sliceValue[T]) Append(s string) error {
+	item, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+	*v.p = append(*v.p, item)
+	return nil
+}
+
+// Replace implements pflag.SliceValue.
+func (v *sliceValue[T]) Replace(ss []string) error {
+	items := make([]T, 0, len(ss))
+	for _, s := range ss {
+		item, err := v.parse(s)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	*v.p = items
+	return nil
+}
+
+// GetSlice implements pflag.SliceValue.
+func (v *sliceValue[T]) GetSlice() []string {
+	out := make([]string, len(*v.p))
+	for i, item := range *v.p {
+		out[i] = item.String()
+	}
+	return out
+}
+
+func newSliceValue[T fmt.Stringer](value []T, typ string, parse func(string) (T, error)) *sliceValue[T] {
+	p := new([]T)
+	*p = append([]T(nil), value...)
+	return &sliceValue[T]{p: p, parse: parse, typ: typ}
+}
+
+// IPSlice defines a list of IP addresses, given comma-separated or by
+// repeating the flag, and returns a pointer to it.
+func IPSlice(fs *flag.FlagSet, name string, value []netip.Addr, usage string) *[]netip.Addr {
+	v := newSliceValue(value, "ipSlice", ParseIP)
+	fs.Var(v, name, usage)
+	return v.p
+}
+
+// IPSlicePflag is IPSlice for a pflag.FlagSet; shorthand may be empty.
+func IPSlicePflag(fs *pflag.FlagSet, name, shorthand string, value []netip.Addr, usage string) *[]netip.Addr {
+	v := newSliceValue(value, "ipSlice", ParseIP)
+	fs.VarP(v, name, shorthand, usage)
+	return v.p
+}
+
+// PrefixSlice defines a list of CIDR prefixes, such as an allowlist given as
+// --allow 10.0.0.0/8,192.168.0.0/16, and returns a pointer to it. Use
+// PrefixesContain to match an address against it.
+func PrefixSlice(fs *flag.FlagSet, name string, value []netip.Prefix, usage string) *[]netip.Prefix {
+	v := newSliceValue(value, "cidrSlice", ParsePrefix)
+	fs.Var(v, name, usage)
+	return v.p
+}
+
+// PrefixSlicePflag is PrefixSlice for a pflag.FlagSet; shorthand may be empty.
+func PrefixSlicePflag(fs *pflag.FlagSet, name, shorthand string, value []netip.Prefix, usage string) *[]netip.Prefix {
+	v := newSliceValue(value, "cidrSlice", ParsePrefix)
+	fs.VarP(v, name, shorthand, usage)
+	return v.p
+}
+
+// PrefixesContain reports whether addr is in any of prefixes. IPv4-mapped
+// IPv6 addresses match IPv4 prefixes.
+func PrefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrString formats addr, printing the zero Addr as "".
+func addrString(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return ""
+	}
+	return addr.String()
+}
+
+// prefixString formats prefix, printing the zero Prefix as "".
+func prefixString(prefix netip.Prefix) string {
+	if !prefix.IsValid() {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,174 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/validator"
+	"github.com/spf13/pflag"
+)
+
+func TestIPAndPrefix(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bind := IP(fs, "bind", netip.MustParseAddr("127.0.0.1"), "bind address")
+	subnet := Prefix(fs, "subnet", netip.Prefix{}, "subnet")
+	if err := fs.Parse([]string{"--bind", "::1", "--subnet", "10.1.0.0/16"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if *bind != netip.MustParseAddr("::1") || *subnet != netip.MustParsePrefix("10.1.0.0/16") {
+		t.Errorf("values = %v %v", *bind, *subnet)
+	}
+	if got := GetString(fs, "bind", ""); got != "::1" {
+		t.Errorf("GetString(bind) = %q, want ::1", got)
+	}
+
+	for _, args := range [][]string{{"--bind", "localhost"}, {"--subnet", "10.0.0.0/33"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		IP(fs, "bind", netip.Addr{}, "")
+		Prefix(fs, "subnet", netip.Prefix{}, "")
+		if err := fs.Parse(args); err == nil || !strings.Contains(err.Error(), "invalid value") {
+			t.Errorf("fs.Parse(%q) error = %v, want invalid value", args, err)
+		}
+	}
+
+	if p, err := ParsePrefix("192.0.2.7"); err != nil || p != netip.MustParsePrefix("192.0.2.7/32") {
+		t.Errorf("ParsePrefix(bare address) = %v, %v", p, err)
+	}
+}
+
+func TestParseListenAddr(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{":8080", ":8080"},
+		{"8080", ":8080"},
+		{"[::]:443", "[::]:443"},
+		{"0.0.0.0:80", "0.0.0.0:80"},
+		{" localhost:9000 ", "localhost:9000"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseListenAddr(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseListenAddr(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", ":0", ":70000", "::1", "host:", "http://x:80"} {
+		if _, err := ParseListenAddr(in); !errors.Is(err, validator.ErrInvalidHostPort) {
+			t.Errorf("ParseListenAddr(%q) error = %v, want ErrInvalidHostPort", in, err)
+		}
+	}
+}
+
+func TestListenAddrAndHostPort(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	listen := ListenAddrPflag(fs, "listen", "l", ":8080", "listen address")
+	redis := HostPortPflag(fs, "redis", "", "localhost:6379", "redis address")
+	if *listen != ":8080" || redis.Host() != "localhost" || redis.Port() != 6379 {
+		t.Errorf("defaults = %q %q", *listen, redis)
+	}
+	if err := fs.Parse([]string{"-l", "[::]:443", "--redis", "[fd00::1]:7000"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if *listen != "[::]:443" || redis.Host() != "fd00::1" || redis.Port() != 7000 || redis.String() != "[fd00::1]:7000" {
+		t.Errorf("values = %q %q %d", *listen, redis.Host(), redis.Port())
+	}
+	if err := fs.Parse([]string{"--redis", ":6379"}); err == nil {
+		t.Error("HostPort should reject an empty host")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ListenAddr() should panic on an invalid default")
+		}
+	}()
+	ListenAddr(flag.NewFlagSet("test", flag.ContinueOnError), "listen", "nope", "")
+}
+
+func TestURLValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	upstream := URL(fs, "upstream", "https://1.0.0.1", &validator.URLOptions{}, "upstream")
+	if upstream.URL().Host != "1.0.0.1" {
+		t.Errorf("default = %v", upstream.URL())
+	}
+	if err := fs.Parse([]string{"--upstream", "http://127.0.0.1/"}); err == nil {
+		t.Error("URL flag should reject localhost with default options")
+	}
+	if err := fs.Parse([]string{"--upstream", "https://1.1.1.1/api"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	if got := upstream.URL(); got.Scheme != "https" || got.Path != "/api" || GetString(fs, "upstream", "") != "https://1.1.1.1/api" {
+		t.Errorf("URL() = %v", got)
+	}
+
+	// The default is validated with the same options as command-line input.
+	if _, err := NewURLValue("http://localhost:8080", &validator.URLOptions{}); err == nil {
+		t.Error("NewURLValue() should reject a localhost default with default options")
+	}
+	local, err := NewURLValue("http://localhost:8080", &validator.URLOptions{AllowLocalhost: true})
+	if err != nil || local.URL().Host != "localhost:8080" {
+		t.Errorf("NewURLValue(localhost, AllowLocalhost) = %v, %v", local, err)
+	}
+
+	empty := URLPflag(pflag.NewFlagSet("test", pflag.ContinueOnError), "u", "", "", nil, "")
+	if empty.URL() != nil || empty.String() != "" {
+		t.Errorf("unset URL = %v", empty.URL())
+	}
+}
+
+func TestPrefixSlice(t *testing.T) {
+	def := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	allow := PrefixSlice(fs, "allow", def, "allowed clients")
+	if err := fs.Parse([]string{"--allow", "10.0.0.0/8, 192.168.0.0/16", "--allow", "fd00::/8"}); err != nil {
+		t.Fatalf("fs.Parse() failed: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.0.0/16", "fd00::/8"}
+	if got := GetStringSlice(fs, "allow", nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStringSlice() = %q, want %q (default replaced, later values appended)", got, want)
+	}
+	if !PrefixesContain(*allow, netip.MustParseAddr("::ffff:10.1.2.3")) || PrefixesContain(*allow, netip.MustParseAddr("127.0.0.1")) {
+		t.Errorf("PrefixesContain() mismatch for %v", *allow)
+	}
+	if err := fs.Parse([]string{"--allow", "10.0.0.0/8,bogus"}); err == nil {
+		t.Error("PrefixSlice should reject an invalid element")
+	}
+
+	var help bytes.Buffer
+	fs.SetOutput(&help)
+	fs.PrintDefaults()
+	if !strings.Contains(help.String(), "(default [127.0.0.0/8])") {
+		t.Errorf("PrintDefaults() = %q, want the default list", help.String())
+	}
+}
+
+func TestIPSlicePflag_FlagFile(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	peers := IPSlicePflag(fs, "peer", "", nil, "peers")
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"peer": ["192.0.2.1", "2001:db8::1"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFlagFilePflag(fs, path); err != nil {
+		t.Fatalf("LoadFlagFilePflag() failed: %v", err)
+	}
+	want := []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}
+	if !reflect.DeepEqual(*peers, want) || !HasFlagPflag(fs, "peer") {
+		t.Errorf("peers = %v, want %v", *peers, want)
+	}
+	if got := DescribePflag(fs).Flags[0].Type; got != "ipSlice" {
+		t.Errorf("Describe type = %q, want ipSlice", got)
+	}
+}
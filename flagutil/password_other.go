@@ -0,0 +1,24 @@
+//go:build !unix
+
+package flagutil
+
+import "os"
+
+// openNoFollow is unavailable; symlinks are refused by the Lstat check alone.
+const openNoFollow = 0
+
+// openNonBlock is unavailable; opening does not block on these platforms'
+// special files the way it does on a Unix FIFO.
+const openNonBlock = 0
+
+// groupOtherReadable always reports false: permission bits are not
+// meaningful outside Unix.
+func groupOtherReadable(os.FileInfo) bool {
+	return false
+}
+
+// ownedByCurrentUser always reports true: file ownership is not checked
+// outside Unix.
+func ownedByCurrentUser(os.FileInfo) bool {
+	return true
+}
@@ -0,0 +1,63 @@
+package flagutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePasswordFile(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPasswordFromFileWithOptions_Trim(t *testing.T) {
+	path := writePasswordFile(t, "  pass word \r\n\n", 0o600)
+
+	got, err := ReadPasswordFromFileWithOptions(path, nil)
+	if err != nil || got != "pass word" {
+		t.Errorf("nil options = %q, %v, want trimmed", got, err)
+	}
+	got, err = ReadPasswordFromFileWithOptions(path, &PasswordFileOptions{TrimNewline: true})
+	if err != nil || got != "  pass word \r\n" {
+		t.Errorf("TrimNewline = %q, %v, want one newline stripped", got, err)
+	}
+	path = writePasswordFile(t, " secret \r\n", 0o600)
+	got, err = ReadPasswordFromFileWithOptions(path, &PasswordFileOptions{TrimNewline: true})
+	if err != nil || got != " secret " {
+		t.Errorf("TrimNewline with CRLF = %q, %v", got, err)
+	}
+}
+
+func TestReadPasswordFromFileWithOptions_MaxSize(t *testing.T) {
+	path := writePasswordFile(t, "0123456789", 0o600)
+	if _, err := ReadPasswordFromFileWithOptions(path, &PasswordFileOptions{MaxSize: 5}); !errors.Is(err, ErrPasswordFileTooLarge) {
+		t.Errorf("MaxSize 5 error = %v, want ErrPasswordFileTooLarge", err)
+	}
+	if got, err := ReadPasswordFromFileWithOptions(path, &PasswordFileOptions{MaxSize: 10}); err != nil || got != "0123456789" {
+		t.Errorf("MaxSize 10 = %q, %v", got, err)
+	}
+
+	large := writePasswordFile(t, strings.Repeat("x", DefaultMaxPasswordFileSize+1), 0o600)
+	if _, err := ReadPasswordFromFileWithOptions(large, &PasswordFileOptions{}); !errors.Is(err, ErrPasswordFileTooLarge) {
+		t.Errorf("zero options on large file error = %v, want ErrPasswordFileTooLarge", err)
+	}
+	if got, err := ReadPasswordFromFile(large); err != nil || len(got) != DefaultMaxPasswordFileSize+1 {
+		t.Errorf("ReadPasswordFromFile() on large file = %d bytes, %v, want no limit", len(got), err)
+	}
+	if got, err := ReadPasswordFromFileWithOptions(large, nil); err != nil || len(got) != DefaultMaxPasswordFileSize+1 {
+		t.Errorf("nil options on large file = %d bytes, %v, want no limit", len(got), err)
+	}
+}
+
+func TestReadPasswordFromFileWithOptions_Directory(t *testing.T) {
+	if _, err := ReadPasswordFromFileWithOptions(t.TempDir(), &PasswordFileOptions{}); !errors.Is(err, ErrPasswordFileNotRegular) {
+		t.Errorf("zero options on dir error = %v, want ErrPasswordFileNotRegular", err)
+	}
+}
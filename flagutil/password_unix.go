@@ -0,0 +1,23 @@
+//go:build unix
+
+package flagutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// openNoFollow makes open fail when the final path element is a symlink.
+const openNoFollow = syscall.O_NOFOLLOW
+
+// openNonBlock keeps opening a FIFO from waiting for a writer.
+const openNonBlock = syscall.O_NONBLOCK
+
+func groupOtherReadable(info os.FileInfo) bool {
+	return info.Mode().Perm()&0o044 != 0
+}
+
+func ownedByCurrentUser(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Geteuid()
+}
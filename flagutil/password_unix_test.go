@@ -0,0 +1,69 @@
+//go:build unix
+
+package flagutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestReadPasswordFromFileWithOptions_Permissions(t *testing.T) {
+	strict := &PasswordFileOptions{RejectGroupOtherReadable: true, RequireOwner: true}
+
+	path := writePasswordFile(t, "secret", 0o644)
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPasswordFromFileWithOptions(path, strict); !errors.Is(err, ErrPasswordFilePermissions) {
+		t.Errorf("mode 0644 error = %v, want ErrPasswordFilePermissions", err)
+	}
+	if _, err := ReadPasswordFromFile(path); err != nil {
+		t.Errorf("ReadPasswordFromFile() should not check permissions, got %v", err)
+	}
+
+	path = writePasswordFile(t, "secret", 0o600)
+	if got, err := ReadPasswordFromFileWithOptions(path, strict); err != nil || got != "secret" {
+		t.Errorf("mode 0600 = %q, %v", got, err)
+	}
+}
+
+func TestReadPasswordFromFileWithOptions_Symlink(t *testing.T) {
+	target := writePasswordFile(t, "secret", 0o600)
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if got, err := ReadPasswordFromFile(link); err != nil || got != "secret" {
+		t.Errorf("ReadPasswordFromFile(link) = %q, %v, want symlink followed", got, err)
+	}
+	if _, err := ReadPasswordFromFileWithOptions(link, &PasswordFileOptions{NoFollow: true}); !errors.Is(err, ErrPasswordFileSymlink) {
+		t.Errorf("NoFollow error = %v, want ErrPasswordFileSymlink", err)
+	}
+}
+
+func TestReadPasswordFromFileWithOptions_FIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	if _, err := ReadPasswordFromFileWithOptions(path, &PasswordFileOptions{}); !errors.Is(err, ErrPasswordFileNotRegular) {
+		t.Errorf("zero options on fifo error = %v, want ErrPasswordFileNotRegular", err)
+	}
+	if _, err := ReadPasswordFromFileWithOptions("/dev/null", &PasswordFileOptions{}); !errors.Is(err, ErrPasswordFileNotRegular) {
+		t.Errorf("zero options on /dev/null error = %v, want ErrPasswordFileNotRegular", err)
+	}
+
+	// ReadPasswordFromFile keeps reading whatever the path names.
+	go func() {
+		if w, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			_, _ = w.WriteString("piped\n")
+			_ = w.Close()
+		}
+	}()
+	if got, err := ReadPasswordFromFile(path); err != nil || got != "piped" {
+		t.Errorf("ReadPasswordFromFile(fifo) = %q, %v, want the piped secret", got, err)
+	}
+}
@@ -14,7 +14,13 @@ func HasFlagPflag(fs *pflag.FlagSet, name string) bool {
 		return false
 	}
 	f := fs.Lookup(name)
-	return f != nil && f.Changed
+	if f == nil {
+		return false
+	}
+	if negation := negationOf(fs, name); negation != "" && fs.Changed(negation) {
+		return true
+	}
+	return f.Changed
 }
 
 // GetFlagValuePflag returns the string value for a flag if it was set.
@@ -23,7 +29,7 @@ func GetFlagValuePflag(fs *pflag.FlagSet, name string) (string, bool) {
 		return "", false
 	}
 	f := fs.Lookup(name)
-	if f == nil || !f.Changed {
+	if f == nil || !HasFlagPflag(fs, name) {
 		return "", false
 	}
 	return f.Value.String(), true
@@ -62,6 +68,42 @@ func GetInt64Pflag(fs *pflag.FlagSet, name string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// GetUintPflag returns flag value as uint or defaultValue when not set/invalid.
+func GetUintPflag(fs *pflag.FlagSet, name string, defaultValue uint) uint {
+	value, ok := GetFlagValuePflag(fs, name)
+	if !ok {
+		return defaultValue
+	}
+	if parsed, err := strconv.ParseUint(value, 10, 0); err == nil {
+		return uint(parsed)
+	}
+	return defaultValue
+}
+
+// GetUint64Pflag returns flag value as uint64 or defaultValue when not set/invalid.
+func GetUint64Pflag(fs *pflag.FlagSet, name string, defaultValue uint64) uint64 {
+	value, ok := GetFlagValuePflag(fs, name)
+	if !ok {
+		return defaultValue
+	}
+	if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return parsed
+	}
+	return defaultValue
+}
+
+// GetFloat64Pflag returns flag value as float64 or defaultValue when not set/invalid.
+func GetFloat64Pflag(fs *pflag.FlagSet, name string, defaultValue float64) float64 {
+	value, ok := GetFlagValuePflag(fs, name)
+	if !ok {
+		return defaultValue
+	}
+	if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+		return parsed
+	}
+	return defaultValue
+}
+
 // GetBoolPflag returns flag value as bool or defaultValue when not set/invalid.
 func GetBoolPflag(fs *pflag.FlagSet, name string, defaultValue bool) bool {
 	value, ok := GetFlagValuePflag(fs, name)
@@ -85,3 +127,54 @@ func GetDurationPflag(fs *pflag.FlagSet, name string, defaultValue time.Duration
 	}
 	return defaultValue
 }
+
+// GetStringSlicePflag returns the values of a list flag, or defaultValue when not set.
+// Values implementing pflag.SliceValue (StringSlice, StringArray, IntSlice, ...) are
+// read natively through GetSlice, so elements containing commas are preserved.
+// Any other flag type yields its single string value.
+func GetStringSlicePflag(fs *pflag.FlagSet, name string, defaultValue []string) []string {
+	if !HasFlagPflag(fs, name) {
+		return defaultValue
+	}
+	f := fs.Lookup(name)
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+	value := f.Value.String()
+	if value == "" {
+		return defaultValue
+	}
+	return []string{value}
+}
+
+// GetStringToStringPflag returns the entries of a map flag, or defaultValue when not set/not a map.
+// stringToString, stringToInt and stringToInt64 flags are read natively; integer
+// values are formatted in base 10.
+func GetStringToStringPflag(fs *pflag.FlagSet, name string, defaultValue map[string]string) map[string]string {
+	if !HasFlagPflag(fs, name) {
+		return defaultValue
+	}
+	switch fs.Lookup(name).Value.Type() {
+	case "stringToString":
+		if m, err := fs.GetStringToString(name); err == nil {
+			return m
+		}
+	case "stringToInt":
+		if m, err := fs.GetStringToInt(name); err == nil {
+			result := make(map[string]string, len(m))
+			for k, v := range m {
+				result[k] = strconv.Itoa(v)
+			}
+			return result
+		}
+	case "stringToInt64":
+		if m, err := fs.GetStringToInt64(name); err == nil {
+			result := make(map[string]string, len(m))
+			for k, v := range m {
+				result[k] = strconv.FormatInt(v, 10)
+			}
+			return result
+		}
+	}
+	return defaultValue
+}
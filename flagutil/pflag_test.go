@@ -2,6 +2,7 @@ package flagutil
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -133,3 +134,109 @@ func TestGetInt64Pflag(t *testing.T) {
 		t.Errorf("GetInt64Pflag(invalid) = %d; want 10", got)
 	}
 }
+
+func TestGetUintPflag_GetUint64Pflag_GetFloat64Pflag(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Uint("workers", 1, "workers")
+	fs.Uint64("total", 1, "total")
+	fs.Float64("ratio", 0.5, "ratio")
+	fs.String("bad", "", "bad")
+	if err := fs.Parse([]string{"--workers", "8", "--total", "18446744073709551615", "--ratio", "0.75", "--bad", "x"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if got := GetUintPflag(fs, "workers", 1); got != 8 {
+		t.Errorf("GetUintPflag(workers) = %d; want 8", got)
+	}
+	if got := GetUint64Pflag(fs, "total", 1); got != 18446744073709551615 {
+		t.Errorf("GetUint64Pflag(total) = %d; want max uint64", got)
+	}
+	if got := GetFloat64Pflag(fs, "ratio", 0.5); got != 0.75 {
+		t.Errorf("GetFloat64Pflag(ratio) = %v; want 0.75", got)
+	}
+	if got := GetUintPflag(fs, "bad", 3); got != 3 {
+		t.Errorf("GetUintPflag(invalid) = %d; want 3", got)
+	}
+	if got := GetUint64Pflag(fs, "bad", 3); got != 3 {
+		t.Errorf("GetUint64Pflag(invalid) = %d; want 3", got)
+	}
+	if got := GetFloat64Pflag(fs, "bad", 0.1); got != 0.1 {
+		t.Errorf("GetFloat64Pflag(invalid) = %v; want 0.1", got)
+	}
+	if got := GetUintPflag(fs, "missing", 4); got != 4 {
+		t.Errorf("GetUintPflag(missing) = %d; want 4", got)
+	}
+	if got := GetUint64Pflag(fs, "missing", 4); got != 4 {
+		t.Errorf("GetUint64Pflag(missing) = %d; want 4", got)
+	}
+	if got := GetFloat64Pflag(fs, "missing", 0.2); got != 0.2 {
+		t.Errorf("GetFloat64Pflag(missing) = %v; want 0.2", got)
+	}
+}
+
+func TestGetStringSlicePflag(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringArray("header", nil, "header")
+	fs.StringSlice("tag", nil, "tag")
+	fs.IntSlice("id", nil, "id")
+	fs.String("single", "", "single")
+	fs.String("empty", "", "empty")
+	args := []string{
+		"--header", "Accept: a, b",
+		"--header", "X-Id: 1",
+		"--tag", "a,b", "--tag", "c",
+		"--id", "1,2",
+		"--single", "x,y",
+		"--empty", "",
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"header", []string{"Accept: a, b", "X-Id: 1"}},
+		{"tag", []string{"a", "b", "c"}},
+		{"id", []string{"1", "2"}},
+		{"single", []string{"x,y"}},
+		{"empty", []string{"default"}},
+		{"missing", []string{"default"}},
+	}
+	for _, tt := range tests {
+		got := GetStringSlicePflag(fs, tt.name, []string{"default"})
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("GetStringSlicePflag(%s) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetStringToStringPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringToString("label", nil, "label")
+	fs.StringToInt("limit", nil, "limit")
+	fs.StringToInt64("quota", nil, "quota")
+	fs.String("plain", "", "plain")
+	args := []string{"--label", "env=prod,team=core", "--limit", "cpu=2", "--quota", "disk=10", "--plain", "a=b"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	def := map[string]string{"default": "yes"}
+	if got := GetStringToStringPflag(fs, "label", def); got["env"] != "prod" || got["team"] != "core" || len(got) != 2 {
+		t.Errorf("GetStringToStringPflag(label) = %v", got)
+	}
+	if got := GetStringToStringPflag(fs, "limit", def); got["cpu"] != "2" || len(got) != 1 {
+		t.Errorf("GetStringToStringPflag(limit) = %v", got)
+	}
+	if got := GetStringToStringPflag(fs, "quota", def); got["disk"] != "10" || len(got) != 1 {
+		t.Errorf("GetStringToStringPflag(quota) = %v", got)
+	}
+	if got := GetStringToStringPflag(fs, "plain", def); got["default"] != "yes" {
+		t.Errorf("GetStringToStringPflag(plain) = %v; want default", got)
+	}
+	if got := GetStringToStringPflag(fs, "missing", def); got["default"] != "yes" {
+		t.Errorf("GetStringToStringPflag(missing) = %v; want default", got)
+	}
+}
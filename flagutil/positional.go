@@ -0,0 +1,193 @@
+package flagutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soulteary/cli-kit/validator"
+)
+
+// Positional describes a named positional argument.
+type Positional struct {
+	// Name identifies the argument in usage ("<src>") and in Get.
+	Name string
+	// Usage is the description shown under "Arguments:" in help.
+	Usage string
+	// Optional arguments may be omitted; they must follow required ones.
+	Optional bool
+	// Variadic takes all remaining arguments; only the last positional may be variadic.
+	Variadic bool
+	// Validate checks each value, e.g. PathArg(nil) or URLArg(nil); nil accepts any.
+	Validate func(value string) error
+}
+
+// Args is a spec for the positional arguments left after flag parsing.
+// Build it with NewArgs, call Parse with fs.Args() and read values by name.
+//
+//	args := flagutil.NewArgs(
+//		flagutil.Positional{Name: "src", Validate: flagutil.PathArg(nil)},
+//		flagutil.Positional{Name: "dst"},
+//	)
+//	if err := args.Parse(fs.Args()); err != nil { ... }
+//	copyFile(args.Get("src"), args.Get("dst"))
+type Args struct {
+	positionals []Positional
+	min, max    int
+	values      map[string][]string
+}
+
+// NewArgs creates a spec for positionals, in order. The number of arguments
+// accepted follows from the spec: one per required positional, plus one per
+// optional positional, plus any number for a trailing variadic one. Use
+// Exact, Min, Max or Range to tighten it. NewArgs panics if an optional
+// positional precedes a required one, a positional other than the last is
+// variadic, or a name repeats, like flag does for other definition errors.
+func NewArgs(positionals ...Positional) *Args {
+	a := &Args{positionals: append([]Positional(nil), positionals...), values: make(map[string][]string)}
+	seen := make(map[string]bool)
+	optional := false
+	for i, p := range a.positionals {
+		switch {
+		case seen[p.Name]:
+			panic(fmt.Sprintf("flagutil: positional %q defined twice", p.Name))
+		case p.Variadic && i != len(a.positionals)-1:
+			panic(fmt.Sprintf("flagutil: variadic positional %q must be last", p.Name))
+		case optional && !p.Optional:
+			panic(fmt.Sprintf("flagutil: required positional %q follows an optional one", p.Name))
+		}
+		seen[p.Name] = true
+		optional = p.Optional
+		if !p.Optional {
+			a.min++
+		}
+		if p.Variadic {
+			a.max = -1
+		} else if a.max >= 0 {
+			a.max++
+		}
+	}
+	return a
+}
+
+// Exact requires exactly n arguments.
+func (a *Args) Exact(n int) *Args {
+	return a.Range(n, n)
+}
+
+// Min requires at least n arguments.
+func (a *Args) Min(n int) *Args {
+	a.min = n
+	return a
+}
+
+// Max accepts at most n arguments; -1 removes the limit.
+func (a *Args) Max(n int) *Args {
+	a.max = n
+	return a
+}
+
+// Range requires between min and max arguments, inclusive.
+func (a *Args) Range(min, max int) *Args {
+	a.min, a.max = min, max
+	return a
+}
+
+// Parse checks the number of args and validates every value, then records
+// them for Get and Values. Errors name the argument, e.g.
+// `missing argument <dst>` or `invalid argument <src> "x": ...`.
+func (a *Args) Parse(args []string) error {
+	a.values = make(map[string][]string)
+	if len(args) < a.min {
+		if len(args) < len(a.positionals) && !a.positionals[len(args)].Optional {
+			return fmt.Errorf("missing argument %s", a.positionals[len(args)].placeholder())
+		}
+		return fmt.Errorf("requires at least %d arg(s), received %d", a.min, len(args))
+	}
+	if a.max >= 0 && len(args) > a.max {
+		if a.max == len(a.positionals) || len(a.positionals) == 0 {
+			return fmt.Errorf("unexpected argument %q", args[a.max])
+		}
+		return fmt.Errorf("accepts at most %d arg(s), received %d", a.max, len(args))
+	}
+
+	values := make(map[string][]string)
+	for i, arg := range args {
+		p, ok := a.positionalAt(i)
+		if !ok {
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+		if p.Validate != nil {
+			if err := p.Validate(arg); err != nil {
+				return fmt.Errorf("invalid argument %s %q: %w", p.placeholder(), arg, err)
+			}
+		}
+		values[p.Name] = append(values[p.Name], arg)
+	}
+	a.values = values
+	return nil
+}
+
+// positionalAt returns the positional that receives the i-th argument.
+func (a *Args) positionalAt(i int) (Positional, bool) {
+	if i < len(a.positionals) {
+		return a.positionals[i], true
+	}
+	if n := len(a.positionals); n > 0 && a.positionals[n-1].Variadic {
+		return a.positionals[n-1], true
+	}
+	return Positional{}, false
+}
+
+// Get returns the value of the named positional, or "" if it was not given.
+// For a variadic positional it returns the first value.
+func (a *Args) Get(name string) string {
+	if v := a.values[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Values returns every value given for the named positional.
+func (a *Args) Values(name string) []string {
+	return append([]string(nil), a.values[name]...)
+}
+
+// Has reports whether the named positional was given.
+func (a *Args) Has(name string) bool {
+	return len(a.values[name]) > 0
+}
+
+// String returns the usage form of the spec, e.g. "<src> <dst> [<mode>] <file>...".
+func (a *Args) String() string {
+	parts := make([]string, 0, len(a.positionals))
+	for _, p := range a.positionals {
+		s := p.placeholder()
+		if p.Variadic {
+			s += "..."
+		}
+		if p.Optional {
+			s = "[" + s + "]"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p Positional) placeholder() string {
+	return "<" + p.Name + ">"
+}
+
+// PathArg adapts validator.ValidatePath for Positional.Validate.
+func PathArg(opts *validator.PathOptions) func(string) error {
+	return func(value string) error {
+		_, err := validator.ValidatePath(value, opts)
+		return err
+	}
+}
+
+// URLArg adapts validator.ValidateURL for Positional.Validate.
+func URLArg(opts *validator.URLOptions) func(string) error {
+	return func(value string) error {
+		return validator.ValidateURL(value, opts)
+	}
+}
@@ -0,0 +1,155 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArgs_Parse(t *testing.T) {
+	errBad := errors.New("bad value")
+	noX := func(v string) error {
+		if strings.Contains(v, "x") {
+			return errBad
+		}
+		return nil
+	}
+	newSpec := func() *Args {
+		return NewArgs(
+			Positional{Name: "src", Validate: noX},
+			Positional{Name: "dst"},
+			Positional{Name: "mode", Optional: true},
+		)
+	}
+	tests := []struct {
+		name    string
+		spec    *Args
+		args    []string
+		want    map[string][]string
+		wantErr string
+	}{
+		{"required only", newSpec(), []string{"a", "b"}, map[string][]string{"src": {"a"}, "dst": {"b"}}, ""},
+		{"optional given", newSpec(), []string{"a", "b", "c"}, map[string][]string{"src": {"a"}, "dst": {"b"}, "mode": {"c"}}, ""},
+		{"missing", newSpec(), []string{"a"}, nil, "missing argument <dst>"},
+		{"extra", newSpec(), []string{"a", "b", "c", "d"}, nil, `unexpected argument "d"`},
+		{"invalid", newSpec(), []string{"ax", "b"}, nil, `invalid argument <src> "ax": bad value`},
+		{"exact", newSpec().Exact(3), []string{"a", "b"}, nil, "requires at least 3 arg(s), received 2"},
+		{"variadic", NewArgs(Positional{Name: "dst"}, Positional{Name: "file", Variadic: true}),
+			[]string{"d", "f1", "f2"}, map[string][]string{"dst": {"d"}, "file": {"f1", "f2"}}, ""},
+		{"variadic max", NewArgs(Positional{Name: "file", Variadic: true}).Max(2),
+			[]string{"f1", "f2", "f3"}, nil, "accepts at most 2 arg(s), received 3"},
+		{"variadic range", NewArgs(Positional{Name: "file", Variadic: true, Optional: true}).Range(1, 2),
+			nil, nil, "requires at least 1 arg(s), received 0"},
+		{"no positionals", NewArgs(), []string{"a"}, nil, `unexpected argument "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.args, err)
+			}
+			for name, want := range tt.want {
+				if got := tt.spec.Values(name); !reflect.DeepEqual(got, want) {
+					t.Errorf("Values(%q) = %q, want %q", name, got, want)
+				}
+				if got := tt.spec.Get(name); got != want[0] || !tt.spec.Has(name) {
+					t.Errorf("Get(%q) = %q, want %q", name, got, want[0])
+				}
+			}
+		})
+	}
+}
+
+func TestArgs_ValidatorErrors(t *testing.T) {
+	spec := NewArgs(Positional{Name: "dir", Validate: PathArg(nil)}, Positional{Name: "url", Validate: URLArg(nil)})
+	if err := spec.Parse([]string{"../etc", "https://1.1.1.1"}); err == nil || !strings.Contains(err.Error(), "<dir>") {
+		t.Errorf("Parse() with traversal = %v, want <dir> error", err)
+	}
+	if err := spec.Parse([]string{"data", "ftp://1.1.1.1"}); err == nil || !strings.Contains(err.Error(), "<url>") {
+		t.Errorf("Parse() with bad scheme = %v, want <url> error", err)
+	}
+	if err := spec.Parse([]string{"data", "https://1.1.1.1"}); err != nil || spec.Get("dir") != "data" {
+		t.Errorf("Parse() = %v, Get(dir) = %q", err, spec.Get("dir"))
+	}
+	if spec.Has("missing") || spec.Get("missing") != "" {
+		t.Error("unknown names should be empty")
+	}
+}
+
+func TestNewArgs_Panics(t *testing.T) {
+	for name, spec := range map[string][]Positional{
+		"variadic not last": {{Name: "a", Variadic: true}, {Name: "b"}},
+		"required after":    {{Name: "a", Optional: true}, {Name: "b"}},
+		"duplicate":         {{Name: "a"}, {Name: "a"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("NewArgs() should panic")
+				}
+			}()
+			NewArgs(spec...)
+		})
+	}
+}
+
+func TestArgs_Usage(t *testing.T) {
+	spec := NewArgs(
+		Positional{Name: "src", Usage: "Source file"},
+		Positional{Name: "dst", Usage: "Destination"},
+		Positional{Name: "extra", Optional: true, Variadic: true},
+	)
+	if got := spec.String(); got != "<src> <dst> [<extra>...]" {
+		t.Errorf("String() = %q", got)
+	}
+
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	fs.Bool("force", false, "Overwrite existing files")
+	u := NewUsage(fs)
+	u.Name = "app copy"
+	u.Args = spec
+	var text bytes.Buffer
+	if err := u.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	want := `Usage: app copy <src> <dst> [<extra>...] [--force]
+
+Arguments:
+  <src>    Source file
+  <dst>    Destination
+
+Flags:
+  --force    Overwrite existing files
+`
+	if text.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", text.String(), want)
+	}
+
+	fs.String("mode", "", "File `mode`")
+	MarkRequired(fs, "mode")
+	var md bytes.Buffer
+	if err := u.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"app copy <src> <dst> [<extra>...] [--force] --mode mode\n", "| `<src>` | Source file |"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("WriteMarkdown() missing %q:\n%s", want, md.String())
+		}
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		fs.Bool(name, false, "")
+	}
+	if got := u.synopsis(); got != "app copy <src> <dst> [<extra>...] [flags]" {
+		t.Errorf("synopsis() with many flags = %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package flagutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// openRegular opens path for reading and returns it with its FileInfo when it
+// is a regular file, and an error wrapping notRegular otherwise. The open does
+// not block, so a FIFO or device, even one swapped in for path after an
+// earlier check, is rejected instead of waited on. flags are added to the
+// open flags, e.g. openNoFollow.
+func openRegular(path string, flags int, notRegular error) (*os.File, os.FileInfo, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|openNonBlock|flags, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, fmt.Errorf("%w: %s", notRegular, path)
+	}
+	return f, info, nil
+}
+
+// readRegular reads a regular file of at most maxSize bytes, returning
+// tooLarge for a larger one.
+func readRegular(path string, maxSize int64, notRegular, tooLarge error) ([]byte, error) {
+	f, info, err := openRegular(path, 0, notRegular)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if info.Size() > maxSize {
+		return nil, tooLarge
+	}
+	// The size may change while reading; never read more than the limit.
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, tooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,218 @@
+package flagutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soulteary/cli-kit/validator"
+)
+
+// DefaultResponseFileDepth is the include depth allowed when
+// ResponseFileOptions.MaxDepth is zero.
+const DefaultResponseFileDepth = 8
+
+// DefaultMaxResponseFileSize is the largest response file read when
+// ResponseFileOptions.MaxSize is zero.
+const DefaultMaxResponseFileSize = 1 << 20
+
+// ErrResponseFileDepth is returned when response files are nested too deeply
+var ErrResponseFileDepth = fmt.Errorf("response files nested too deeply")
+
+// ErrResponseFileCycle is returned when a response file includes itself
+var ErrResponseFileCycle = fmt.Errorf("response file includes itself")
+
+// ErrResponseFileSyntax is returned for unterminated quotes and escapes
+var ErrResponseFileSyntax = fmt.Errorf("response file syntax error")
+
+// ErrResponseFileTooLarge is returned when a response file exceeds the size limit
+var ErrResponseFileTooLarge = fmt.Errorf("response file is too large")
+
+// ResponseFileOptions configures ExpandResponseFiles. The zero value uses
+// the defaults.
+type ResponseFileOptions struct {
+	// Path is passed to validator.ValidatePath for every file; set AllowedDirs
+	// to restrict where response files may live. nil uses ValidatePath defaults.
+	Path *validator.PathOptions
+	// MaxDepth limits nested includes; 0 uses DefaultResponseFileDepth.
+	MaxDepth int
+	// MaxSize is the largest file accepted, in bytes; 0 uses DefaultMaxResponseFileSize.
+	MaxSize int64
+}
+
+// ExpandResponseFiles returns args with every "@file" argument replaced by
+// the arguments read from file. Files are split like a POSIX shell does:
+// whitespace separates arguments, single quotes keep text literally, double
+// quotes allow \" \\ \$ and \` escapes, a backslash outside quotes escapes the
+// next character, and an unquoted # starting a word comments out the rest of
+// the line. No variables or globs are expanded.
+//
+// Arguments read from a file may name further files; relative paths are
+// relative to the working directory at every level. Including a file that is
+// already being expanded returns ErrResponseFileCycle.
+//
+// "@@text" passes "@text" through unchanged, "@" alone is kept, and nothing
+// after a "--" argument is expanded.
+func ExpandResponseFiles(args []string, opts *ResponseFileOptions) ([]string, error) {
+	if opts == nil {
+		opts = &ResponseFileOptions{}
+	}
+	e := &responseExpander{opts: opts, maxDepth: opts.MaxDepth, maxSize: opts.MaxSize}
+	if e.maxDepth <= 0 {
+		e.maxDepth = DefaultResponseFileDepth
+	}
+	if e.maxSize <= 0 {
+		e.maxSize = DefaultMaxResponseFileSize
+	}
+	if err := e.expand(args); err != nil {
+		return nil, err
+	}
+	return e.out, nil
+}
+
+type responseExpander struct {
+	opts       *ResponseFileOptions
+	maxDepth   int
+	maxSize    int64
+	out        []string
+	stack      []string
+	terminated bool
+}
+
+func (e *responseExpander) expand(args []string) error {
+	for _, arg := range args {
+		switch {
+		case e.terminated:
+			e.out = append(e.out, arg)
+		case arg == "--":
+			e.terminated = true
+			e.out = append(e.out, arg)
+		case strings.HasPrefix(arg, "@@"):
+			e.out = append(e.out, arg[1:])
+		case len(arg) > 1 && arg[0] == '@':
+			if err := e.include(arg[1:]); err != nil {
+				return err
+			}
+		default:
+			e.out = append(e.out, arg)
+		}
+	}
+	return nil
+}
+
+// include expands one response file.
+func (e *responseExpander) include(path string) error {
+	safePath, err := validator.ValidatePath(path, e.opts.Path)
+	if err != nil {
+		return fmt.Errorf("response file %s: %w", path, err)
+	}
+	for _, open := range e.stack {
+		if open == safePath {
+			return fmt.Errorf("%w: %s", ErrResponseFileCycle, path)
+		}
+	}
+	if len(e.stack) >= e.maxDepth {
+		return fmt.Errorf("%w: %s (limit %d)", ErrResponseFileDepth, path, e.maxDepth)
+	}
+
+	data, err := e.read(safePath)
+	if err != nil {
+		return fmt.Errorf("response file %s: %w", path, err)
+	}
+	words, err := splitResponseFile(string(data))
+	if err != nil {
+		return fmt.Errorf("%w: %s:%v", ErrResponseFileSyntax, path, err)
+	}
+
+	e.stack = append(e.stack, safePath)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+	return e.expand(words)
+}
+
+func (e *responseExpander) read(path string) ([]byte, error) {
+	return readRegular(path, e.maxSize, validator.ErrNotAFile, ErrResponseFileTooLarge)
+}
+
+// splitResponseFile splits s into words with shell-like quoting. Errors are
+// prefixed with the line number where the problem starts, e.g. "3: unterminated quote".
+func splitResponseFile(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		line    = 1
+		quote   byte
+		quoteAt int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\n' {
+			line++
+		}
+		switch quote {
+		case '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+			continue
+		case '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0:
+				i++
+				if s[i] == '\n' {
+					line++
+				} else {
+					word.WriteByte(s[i])
+				}
+			default:
+				word.WriteByte(c)
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case '#':
+			if inWord {
+				word.WriteByte(c)
+				continue
+			}
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+		case '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("%d: trailing backslash", line)
+			}
+			i++
+			if s[i] == '\n' {
+				// Line continuation.
+				line++
+				continue
+			}
+			word.WriteByte(s[i])
+			inWord = true
+		case '\'', '"':
+			quote, quoteAt = c, line
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%d: unterminated %c quote", quoteAt, quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
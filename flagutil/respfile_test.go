@@ -0,0 +1,128 @@
+package flagutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soulteary/cli-kit/validator"
+)
+
+func writeResponseFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSplitResponseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{"words", "--port 80\n\t-v", []string{"--port", "80", "-v"}, ""},
+		{"single quotes", `--name 'a b \n "c"'`, []string{"--name", `a b \n "c"`}, ""},
+		{"double quotes", `"a \"b\" \\ \$x \n"`, []string{`a "b" \ $x \n`}, ""},
+		{"adjacent quotes", `--label=a'b c'"d"`, []string{"--label=ab cd"}, ""},
+		{"empty quoted", `'' ""`, []string{"", ""}, ""},
+		{"backslash", `a\ b \#c`, []string{"a b", "#c"}, ""},
+		{"continuation", "--a \\\n  1", []string{"--a", "1"}, ""},
+		{"comments", "# header\n-v # trailing\nx#y", []string{"-v", "x#y"}, ""},
+		{"quoted newline", "'a\nb'", []string{"a\nb"}, ""},
+		{"unterminated", "ok\n'open", nil, "2: unterminated ' quote"},
+		{"trailing backslash", `a\`, nil, "1: trailing backslash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitResponseFile(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("splitResponseFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitResponseFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := writeResponseFile(t, dir, "inner.args", "--tag 'release build'\n@@literal\n")
+	outer := writeResponseFile(t, dir, "outer.args", "# CI flags\n--port 80 @"+inner+"\n")
+
+	got, err := ExpandResponseFiles([]string{"serve", "@" + outer, "-v", "@", "--", "@" + outer}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"serve", "--port", "80", "--tag", "release build", "@literal", "-v", "@", "--", "@" + outer}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandResponseFiles() = %q, want %q", got, want)
+	}
+
+	// The same file may appear twice as long as it does not include itself.
+	if _, err := ExpandResponseFiles([]string{"@" + inner, "@" + inner}, nil); err != nil {
+		t.Errorf("repeated include: %v", err)
+	}
+}
+
+func TestExpandResponseFiles_Errors(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.args")
+	b := writeResponseFile(t, dir, "b.args", "@"+a)
+	writeResponseFile(t, dir, "a.args", "@"+b)
+	bad := writeResponseFile(t, dir, "bad.args", "-v\n\"open")
+
+	var chain []string
+	for i := range 3 {
+		next := ""
+		if i > 0 {
+			next = "@" + chain[i-1]
+		}
+		chain = append(chain, writeResponseFile(t, dir, "chain"+strings.Repeat("x", i), next))
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		opts    *ResponseFileOptions
+		wantErr error
+		wantMsg string
+	}{
+		{"cycle", []string{"@" + a}, nil, ErrResponseFileCycle, ""},
+		{"depth", []string{"@" + chain[2]}, &ResponseFileOptions{MaxDepth: 2}, ErrResponseFileDepth, ""},
+		{"syntax", []string{"@" + bad}, nil, ErrResponseFileSyntax, "bad.args:2: unterminated \" quote"},
+		{"too large", []string{"@" + bad}, &ResponseFileOptions{MaxSize: 4}, ErrResponseFileTooLarge, ""},
+		{"missing", []string{"@" + filepath.Join(dir, "missing")}, nil, os.ErrNotExist, ""},
+		{"traversal", []string{"@../etc/passwd"}, nil, nil, "path traversal"},
+		{"outside allowed dirs", []string{"@" + b}, &ResponseFileOptions{
+			Path: &validator.PathOptions{AllowRelative: true, CheckTraversal: true, AllowedDirs: []string{t.TempDir()}},
+		}, nil, "not under allowed directories"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExpandResponseFiles(tt.args, tt.opts)
+			if err == nil {
+				t.Fatal("ExpandResponseFiles() want error, got nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExpandResponseFiles() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("ExpandResponseFiles() error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
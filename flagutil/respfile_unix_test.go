@@ -0,0 +1,33 @@
+//go:build unix
+
+package flagutil
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/soulteary/cli-kit/validator"
+)
+
+func TestExpandResponseFiles_FIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo.args")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := ExpandResponseFiles([]string{"@" + path}, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, validator.ErrNotAFile) {
+			t.Errorf("ExpandResponseFiles(@fifo) error = %v, want validator.ErrNotAFile", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ExpandResponseFiles(@fifo) blocked opening the FIFO")
+	}
+}
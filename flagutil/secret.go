@@ -0,0 +1,181 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/soulteary/cli-kit/env"
+	"github.com/spf13/pflag"
+)
+
+// RedactedValue is what SecretValue.String returns in place of the secret.
+const RedactedValue = "[REDACTED]"
+
+// secretStdin is where the "-" form reads from; replaced in tests.
+var secretStdin io.Reader = os.Stdin
+
+// SecretSource describes where a SecretValue was read from.
+type SecretSource string
+
+// Secret sources.
+const (
+	SecretUnset   SecretSource = ""
+	SecretLiteral SecretSource = "literal"
+	SecretFile    SecretSource = "file"
+	SecretEnv     SecretSource = "env"
+	SecretFD      SecretSource = "fd"
+	SecretStdin   SecretSource = "stdin"
+)
+
+// SecretValue is a flag value for passwords, tokens and keys. Besides a
+// literal value, Set accepts indirect forms that keep the secret out of
+// process listings:
+//
+//	@/path/to/file  read from a file with ReadPasswordFromFile
+//	env:VAR         read from environment variable VAR
+//	fd:3            read from an inherited file descriptor, which is left open
+//	-               read from standard input
+//
+// String always returns RedactedValue, so help output, GetFlagValue and
+// config dumps never expose the secret; use Value to read it.
+// SecretValue implements flag.Value and pflag.Value.
+type SecretValue struct {
+	name   string
+	value  string
+	source SecretSource
+	warn   io.Writer
+}
+
+// NewSecretValue creates an empty SecretValue. name is used in warnings.
+func NewSecretValue(name string) *SecretValue {
+	return &SecretValue{name: name}
+}
+
+// String returns RedactedValue regardless of the stored secret.
+func (s *SecretValue) String() string {
+	return RedactedValue
+}
+
+// Set stores the secret, resolving the indirect forms described on SecretValue.
+func (s *SecretValue) Set(v string) error {
+	value, source, err := readSecret(v)
+	if err != nil {
+		return err
+	}
+	if source == SecretLiteral && s.warn != nil {
+		fmt.Fprintf(s.warn, "warning: secret flag --%s was given as a literal value and may be visible to other users; use @file, env:VAR, fd:N or - instead\n", s.name)
+	}
+	s.value, s.source = value, source
+	return nil
+}
+
+// withoutLiteralWarning runs set with the literal warning of a SecretValue v
+// suppressed, for values that did not come from the command line.
+func withoutLiteralWarning(v flag.Value, set func() error) error {
+	s, ok := v.(*SecretValue)
+	if !ok || s.warn == nil {
+		return set()
+	}
+	warn := s.warn
+	s.warn = nil
+	defer func() { s.warn = warn }()
+	return set()
+}
+
+// Type implements pflag.Value.
+func (s *SecretValue) Type() string {
+	return "secret"
+}
+
+// Value returns the secret.
+func (s *SecretValue) Value() string {
+	return s.value
+}
+
+// Source reports where the secret was read from, or SecretUnset.
+func (s *SecretValue) Source() SecretSource {
+	return s.source
+}
+
+// WarnLiteral makes Set write a warning to w when the secret is passed as a
+// literal command-line value. A nil w disables the warning.
+func (s *SecretValue) WarnLiteral(w io.Writer) *SecretValue {
+	s.warn = w
+	return s
+}
+
+func readSecret(v string) (string, SecretSource, error) {
+	switch {
+	case v == "-":
+		value, err := readSecretFrom(secretStdin)
+		if err != nil {
+			return "", "", fmt.Errorf("read secret from stdin: %w", err)
+		}
+		return value, SecretStdin, nil
+	case strings.HasPrefix(v, "@"):
+		value, err := ReadPasswordFromFile(v[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return value, SecretFile, nil
+	case strings.HasPrefix(v, "env:"):
+		key := v[len("env:"):]
+		value := env.Get(key, "")
+		if value == "" {
+			return "", "", fmt.Errorf("environment variable %s is not set", key)
+		}
+		return value, SecretEnv, nil
+	case strings.HasPrefix(v, "fd:"):
+		fd, err := strconv.Atoi(v[len("fd:"):])
+		if err != nil || fd < 0 {
+			return "", "", fmt.Errorf("invalid file descriptor %q", v[len("fd:"):])
+		}
+		// Read through a duplicate so the inherited descriptor stays open,
+		// e.g. for fd:0, and can be read again.
+		dup, err := dupFD(fd)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid file descriptor %d: %w", fd, err)
+		}
+		f := os.NewFile(uintptr(dup), v)
+		defer f.Close()
+		value, err := readSecretFrom(f)
+		if err != nil {
+			return "", "", fmt.Errorf("read secret from fd %d: %w", fd, err)
+		}
+		return value, SecretFD, nil
+	default:
+		return v, SecretLiteral, nil
+	}
+}
+
+func readSecretFrom(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, DefaultMaxPasswordFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > DefaultMaxPasswordFileSize {
+		return "", fmt.Errorf("secret exceeds %d bytes", DefaultMaxPasswordFileSize)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Secret defines a secret flag on fs and returns its value.
+func Secret(fs *flag.FlagSet, name, usage string) *SecretValue {
+	s := NewSecretValue(name)
+	fs.Var(s, name, usage)
+	return s
+}
+
+// SecretPflag defines a secret flag on a pflag.FlagSet and returns its value.
+// shorthand may be empty.
+func SecretPflag(fs *pflag.FlagSet, name, shorthand, usage string) *SecretValue {
+	s := NewSecretValue(name)
+	fs.VarP(s, name, shorthand, usage)
+	// pflag prints any DefValue it does not recognise as a zero value.
+	fs.Lookup(name).DefValue = ""
+	return s
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package flagutil
+
+import "fmt"
+
+// dupFD is unavailable: without it the fd:N form would close the
+// inherited descriptor, so it is refused.
+func dupFD(fd int) (int, error) {
+	return -1, fmt.Errorf("reading secrets from file descriptors is not supported on this platform")
+}
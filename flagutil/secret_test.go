@@ -0,0 +1,149 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestSecretValue_Set(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "password.txt")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SECRET", "from-env")
+	t.Setenv("TEST_SECRET_EMPTY", "")
+
+	originalStdin := secretStdin
+	defer func() { secretStdin = originalStdin }()
+	secretStdin = strings.NewReader("from-stdin\n")
+
+	tests := []struct {
+		name       string
+		input      string
+		want       string
+		wantSource SecretSource
+		wantErr    bool
+	}{
+		{"literal", "hunter2", "hunter2", SecretLiteral, false},
+		{"file", "@" + file, "from-file", SecretFile, false},
+		{"missing file", "@" + filepath.Join(dir, "missing"), "", SecretUnset, true},
+		{"env", "env:TEST_SECRET", "from-env", SecretEnv, false},
+		{"empty env", "env:TEST_SECRET_EMPTY", "", SecretUnset, true},
+		{"stdin", "-", "from-stdin", SecretStdin, false},
+		{"invalid fd", "fd:abc", "", SecretUnset, true},
+		{"negative fd", "fd:-1", "", SecretUnset, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSecretValue("password")
+			err := s.Set(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if s.Value() != tt.want || s.Source() != tt.wantSource {
+				t.Errorf("Set(%q) = %q from %q, want %q from %q", tt.input, s.Value(), s.Source(), tt.want, tt.wantSource)
+			}
+			if s.String() != RedactedValue {
+				t.Errorf("String() = %q, want %q", s.String(), RedactedValue)
+			}
+		})
+	}
+}
+
+func TestSecretValue_StdinTooLarge(t *testing.T) {
+	originalStdin := secretStdin
+	defer func() { secretStdin = originalStdin }()
+	secretStdin = strings.NewReader(strings.Repeat("x", DefaultMaxPasswordFileSize+1))
+
+	if err := NewSecretValue("token").Set("-"); err == nil {
+		t.Error("Set(-) should reject oversized input")
+	}
+}
+
+func TestSecret_Redaction(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	s := Secret(fs, "db-password", "Database password")
+	if err := fs.Parse([]string{"--db-password=hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Value() != "hunter2" {
+		t.Errorf("Value() = %q, want hunter2", s.Value())
+	}
+	if v, ok := GetFlagValue(fs, "db-password"); !ok || v != RedactedValue {
+		t.Errorf("GetFlagValue() = %q, %v, want redacted", v, ok)
+	}
+	fs.PrintDefaults()
+	if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "default") {
+		t.Errorf("PrintDefaults() = %q, should not show a value or default", out.String())
+	}
+
+	var help bytes.Buffer
+	if err := NewUsage(fs).WriteText(&help); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(help.String(), "--db-password secret    Database password\n") {
+		t.Errorf("WriteText() = %q", help.String())
+	}
+}
+
+func TestSecretPflag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	s := SecretPflag(fs, "token", "t", "API token")
+	if err := fs.Parse([]string{"-t", "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Value() != "abc" {
+		t.Errorf("Value() = %q, want abc", s.Value())
+	}
+	fs.PrintDefaults()
+	if want := "  -t, --token secret   API token\n"; out.String() != want {
+		t.Errorf("PrintDefaults() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSecretValue_WarnLiteral(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	var warn bytes.Buffer
+	s := Secret(fs, "token", "API token").WarnLiteral(&warn)
+
+	if err := fs.Parse([]string{"-token", "env:TEST_SECRET_UNSET_FOR_WARN"}); err == nil {
+		t.Fatal("Parse() should fail for an unset env source")
+	}
+	if err := s.Set("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warn.String(), "--token was given as a literal value") {
+		t.Errorf("warning = %q", warn.String())
+	}
+	if strings.Contains(warn.String(), "hunter2") {
+		t.Error("warning must not contain the secret")
+	}
+
+	// Values applied from bound environment variables are not command-line literals.
+	warn.Reset()
+	fs2 := flag.NewFlagSet("app", flag.ContinueOnError)
+	s2 := Secret(fs2, "token", "API token").WarnLiteral(&warn)
+	binding := BindEnv(fs2, "APP")
+	t.Setenv("APP_TOKEN", "from-env")
+	if err := fs2.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := binding.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if s2.Value() != "from-env" || warn.Len() != 0 {
+		t.Errorf("Apply() value = %q, warning = %q", s2.Value(), warn.String())
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package flagutil
+
+import "syscall"
+
+// dupFD duplicates an inherited descriptor so reading it through an *os.File
+// and closing that file leaves the original open.
+func dupFD(fd int) (int, error) {
+	return syscall.Dup(fd)
+}
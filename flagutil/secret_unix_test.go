@@ -0,0 +1,63 @@
+//go:build unix
+
+package flagutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestSecretValue_FD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	fd, err := syscall.Dup(int(r.Fd()))
+	if err != nil {
+		t.Skipf("dup not supported: %v", err)
+	}
+	if _, err := w.WriteString("from-fd\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	s := NewSecretValue("token")
+	if err := s.Set("fd:" + strconv.Itoa(fd)); err != nil {
+		t.Fatalf("Set(fd) failed: %v", err)
+	}
+	if s.Value() != "from-fd" || s.Source() != SecretFD {
+		t.Errorf("Set(fd) = %q from %q", s.Value(), s.Source())
+	}
+}
+
+func TestSecretValue_FDLeftOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("from-fd\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fd := int(f.Fd())
+
+	s := NewSecretValue("token")
+	for i := range 2 {
+		if err := s.Set("fd:" + strconv.Itoa(fd)); err != nil || s.Value() != "from-fd" {
+			t.Fatalf("Set(fd) #%d = %q, %v", i+1, s.Value(), err)
+		}
+		var st syscall.Stat_t
+		if err := syscall.Fstat(fd, &st); err != nil {
+			t.Fatalf("fd %d is no longer valid after Set: %v", fd, err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package flagutil
+
+import (
+	"flag"
+
+	"github.com/spf13/pflag"
+)
+
+// Source tells where the value of a flag came from.
+type Source int
+
+const (
+	// SourceDefault means the flag was not set and holds its default.
+	SourceDefault Source = iota
+	// SourceCLI means the flag was given on the command line.
+	SourceCLI
+	// SourceEnv means the flag was set from its bound environment variable (see EnvBinding.Apply).
+	SourceEnv
+	// SourceFile means the flag was set from a config file (see SetFromMap and LoadFlagFile).
+	SourceFile
+)
+
+// String returns "default", "cli", "env" or "file".
+func (s Source) String() string {
+	switch s {
+	case SourceCLI:
+		return "cli"
+	case SourceEnv:
+		return "env"
+	case SourceFile:
+		return "file"
+	default:
+		return "default"
+	}
+}
+
+// FlagSource reports where the value of flag name came from. HasFlag is true
+// for every source but SourceDefault; FlagSource tells them apart, e.g. to
+// let a CLI value override a setting that a file-sourced one may not.
+func FlagSource(fs *flag.FlagSet, name string) Source {
+	if fs == nil || !HasFlag(fs, name) {
+		return SourceDefault
+	}
+	return recordedSource(fs, flagNames(fs, name)[0])
+}
+
+// FlagSourcePflag is FlagSource for a pflag.FlagSet.
+func FlagSourcePflag(fs *pflag.FlagSet, name string) Source {
+	if fs == nil || !HasFlagPflag(fs, name) {
+		return SourceDefault
+	}
+	return recordedSource(fs, name)
+}
+
+// recordedSource returns the source recorded for a set flag; flags set
+// without a record were given on the command line.
+func recordedSource(key any, name string) Source {
+	source := SourceCLI
+	readMeta(key, func(m *setMeta) {
+		if s, ok := m.sources[name]; ok {
+			source = s
+		}
+	})
+	return source
+}
+
+// recordSource notes that flag name was set from source.
+func recordSource(key any, name string, source Source) {
+	updateMeta(key, func(m *setMeta) { m.sources[name] = source })
+}
@@ -0,0 +1,147 @@
+package flagutil
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/soulteary/cli-kit/internal/suggest"
+	"github.com/spf13/pflag"
+)
+
+// UnknownFlagError is returned by Parse and ParsePflag when args contain a
+// flag that is not defined. Its message adds the closest defined flags, e.g.
+// "flag provided but not defined: -prot (did you mean --port?)".
+type UnknownFlagError struct {
+	// Flag is the unknown name as given, without dashes.
+	Flag string
+	// Suggestions are defined flags close to Flag, with dashes ("--port", "-p").
+	Suggestions []string
+	// Err is the error reported by the flag package.
+	Err error
+}
+
+func (e *UnknownFlagError) Error() string {
+	return e.Err.Error() + suggest.Phrase(e.Suggestions, func(s string) string { return s })
+}
+
+func (e *UnknownFlagError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses args like fs.Parse, but reports an undefined flag as an
+// *UnknownFlagError with suggestions. The error and usage are written to the
+// FlagSet output and fs.ErrorHandling() is honoured, as fs.Parse does.
+func Parse(fs *flag.FlagSet, args []string) error {
+	for _, tok := range TokenizeArgs(args, SpecFromFlagSet(fs)) {
+		if tok.Kind != ArgFlag {
+			continue
+		}
+		name := tok.Names[0]
+		if fs.Lookup(name) != nil || name == "h" || name == "help" {
+			continue
+		}
+		// Parse what precedes the unknown flag, as the flag package would.
+		if err := fs.Parse(args[:tok.Index]); err != nil {
+			return err
+		}
+		return failStd(fs, &UnknownFlagError{
+			Flag:        name,
+			Suggestions: flagSuggestions(stdFlagSet{fs}, name),
+			Err:         fmt.Errorf("flag provided but not defined: -%s", name),
+		})
+	}
+	return fs.Parse(args)
+}
+
+// failStd reports err the way flag.FlagSet.Parse does.
+func failStd(fs *flag.FlagSet, err error) error {
+	fmt.Fprintln(fs.Output(), err)
+	if fs.Usage != nil {
+		fs.Usage()
+	} else {
+		if fs.Name() == "" {
+			fmt.Fprintf(fs.Output(), "Usage:\n")
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		}
+		fs.PrintDefaults()
+	}
+	switch fs.ErrorHandling() {
+	case flag.ExitOnError:
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// ParsePflag is Parse for a pflag.FlagSet. pflag exits or panics before
+// returning for ExitOnError and PanicOnError sets, so suggestions are only
+// added with ContinueOnError.
+func ParsePflag(fs *pflag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	var notExist *pflag.NotExistError
+	if errors.As(err, &notExist) {
+		return &UnknownFlagError{
+			Flag:        notExist.GetSpecifiedName(),
+			Suggestions: flagSuggestions(pflagFlagSet{fs}, notExist.GetSpecifiedName()),
+			Err:         err,
+		}
+	}
+	return err
+}
+
+// flagSuggestions returns visible flags close to name. Aliases are matched
+// too and suggested as their canonical flag.
+func flagSuggestions(fs flagSet, name string) []string {
+	display := make(map[string]string)
+	var candidates []string
+	add := func(candidate, shown string) {
+		if _, ok := display[candidate]; !ok {
+			display[candidate] = shown
+			candidates = append(candidates, candidate)
+		}
+	}
+	hidden := make(map[string]bool)
+	aliases := make(map[string]string)
+	readMeta(fs.key(), func(m *setMeta) {
+		for n := range m.hidden {
+			hidden[n] = true
+		}
+		for alias, canonical := range m.aliases {
+			aliases[alias] = canonical
+		}
+	})
+	for _, f := range fs.all() {
+		if canonical, ok := aliases[f.Name]; ok {
+			add(f.Name, flagDisplayName(canonical))
+			continue
+		}
+		if hidden[f.Name] || f.Hidden || f.Deprecated != "" {
+			continue
+		}
+		add(f.Name, flagDisplayName(f.Name))
+		if f.Shorthand != "" {
+			add(f.Shorthand, "-"+f.Shorthand)
+		}
+	}
+
+	var suggestions []string
+	for _, match := range suggest.Suggest(name, candidates) {
+		if shown := display[match]; !slices.Contains(suggestions, shown) {
+			suggestions = append(suggestions, shown)
+		}
+	}
+	return suggestions
+}
+
+// flagDisplayName formats a flag name the way help output does.
+func flagDisplayName(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
@@ -0,0 +1,113 @@
+package flagutil
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestParse_UnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	name := fs.String("name", "", "Name")
+	fs.Int("port", 0, "Port")
+	fs.Bool("v", false, "Verbose")
+	fs.String("listen-addr", "", "Listen address")
+	fs.String("internal", "", "Internal")
+	Alias(fs, "bind", "listen-addr")
+	Hide(fs, "internal")
+
+	tests := []struct {
+		name string
+		args []string
+		flag string
+		want []string
+	}{
+		{"transposed", []string{"-name", "x", "--prot", "80"}, "prot", []string{"--port"}},
+		{"with value", []string{"-prot=80"}, "prot", []string{"--port"}},
+		{"alias", []string{"-bnid", ":80"}, "bnid", []string{"--listen-addr"}},
+		{"hidden not suggested", []string{"-internl"}, "internl", nil},
+		{"single letter", []string{"-w"}, "w", []string{"-v"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			err := Parse(fs, tt.args)
+			var unknown *UnknownFlagError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("Parse(%q) = %v, want *UnknownFlagError", tt.args, err)
+			}
+			if unknown.Flag != tt.flag || !reflect.DeepEqual(unknown.Suggestions, tt.want) {
+				t.Errorf("UnknownFlagError = %+v, want flag %q suggestions %q", unknown, tt.flag, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), err.Error()+"\nUsage of app:\n") {
+				t.Errorf("output = %q, want error then usage", out.String())
+			}
+		})
+	}
+	if *name != "x" {
+		t.Errorf("flags before the unknown flag should be parsed, name = %q", *name)
+	}
+
+	err := Parse(fs, []string{"-prot", "80"})
+	if want := "flag provided but not defined: -prot (did you mean --port?)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestParse_Valid(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	port := fs.Int("port", 0, "Port")
+	if err := Parse(fs, []string{"-port", "80", "file", "--unknown"}); err != nil {
+		t.Fatalf("Parse() flags after positionals are positional, got %v", err)
+	}
+	if *port != 80 || fs.NArg() != 2 {
+		t.Errorf("port = %d, args = %q", *port, fs.Args())
+	}
+	if err := Parse(fs, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Parse(-h) = %v, want ErrHelp", err)
+	}
+	if err := Parse(fs, []string{"-port", "x"}); err == nil || errors.As(err, new(*UnknownFlagError)) {
+		t.Errorf("Parse() invalid value = %v, want a plain flag error", err)
+	}
+}
+
+func TestParsePflag_UnknownFlag(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	fs.IntP("port", "p", 0, "Port")
+	fs.BoolP("verbose", "v", false, "Verbose")
+	fs.String("old", "", "Old")
+	_ = fs.MarkDeprecated("old", "gone")
+
+	err := ParsePflag(fs, []string{"--prot", "80"})
+	var unknown *UnknownFlagError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Suggestions, []string{"--port"}) {
+		t.Fatalf("ParsePflag(--prot) = %v", err)
+	}
+	if want := "unknown flag: --prot (did you mean --port?)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	var notExist *pflag.NotExistError
+	if !errors.As(err, &notExist) {
+		t.Error("UnknownFlagError should unwrap to *pflag.NotExistError")
+	}
+
+	err = ParsePflag(fs, []string{"-vo"})
+	if !errors.As(err, &unknown) || unknown.Flag != "o" || !reflect.DeepEqual(unknown.Suggestions, []string{"-p", "-v"}) {
+		t.Errorf("ParsePflag(-vo) = %v (%+v)", err, unknown)
+	}
+	if err := ParsePflag(fs, []string{"--olt"}); !errors.As(err, &unknown) || len(unknown.Suggestions) != 0 {
+		t.Errorf("deprecated flags should not be suggested, got %v", err)
+	}
+	if err := ParsePflag(fs, []string{"-p", "1"}); err != nil {
+		t.Errorf("ParsePflag() = %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package flagutil
+
+// stdoutWidth is unavailable; Usage falls back to $COLUMNS.
+func stdoutWidth() int { return 0 }
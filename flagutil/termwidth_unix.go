@@ -0,0 +1,20 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package flagutil
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// stdoutWidth returns the column count of the terminal on stdout, or 0 when
+// stdout is not a terminal.
+func stdoutWidth() int {
+	var ws struct{ Row, Col, Xpixel, Ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
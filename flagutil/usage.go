@@ -0,0 +1,610 @@
+package flagutil
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// defaultUsageWidth is the text wrap width when the terminal width is unknown.
+const defaultUsageWidth = 80
+
+// maxUsageFlagColumn caps the width of the flag column in text output;
+// longer flag specs put their description on the next line.
+const maxUsageFlagColumn = 32
+
+// maxSynopsisFlags is the most flags spelled out in a usage line built from
+// Usage.Args; more are summarized as "[flags]".
+const maxSynopsisFlags = 4
+
+// Usage renders help for a FlagSet as aligned plain text, Markdown or a roff
+// man page. Flags are listed by group (see SetGroup) with their default value,
+// bound environment variable (see BindEnv), allowed values (see Enum) and
+// constraints (see MarkRequired and related functions).
+// Hidden flags (see Hide, pflag's MarkHidden) and deprecated pflag flags are omitted.
+type Usage struct {
+	// Name is the program name; defaults to the FlagSet name.
+	Name string
+	// Synopsis replaces the default "Name [flags]" usage line.
+	Synopsis string
+	// Args adds positional arguments to the usage line, e.g.
+	// "app copy <src> <dst> [--force]", and lists them under "Arguments:".
+	Args *Args
+	// Description is printed below the usage line.
+	Description string
+	// Width is the wrap width of text output; 0 uses the width of the
+	// terminal on stdout, then $COLUMNS, falling back to 80.
+	Width int
+
+	fs flagSet
+}
+
+// NewUsage creates a Usage renderer for fs.
+func NewUsage(fs *flag.FlagSet) *Usage {
+	return &Usage{Name: fs.Name(), fs: stdFlagSet{fs}}
+}
+
+// NewUsagePflag creates a Usage renderer for a pflag.FlagSet.
+func NewUsagePflag(fs *pflag.FlagSet) *Usage {
+	return &Usage{Name: fs.Name(), fs: pflagFlagSet{fs}}
+}
+
+// SetGroup places the named flags under a heading in generated help.
+// Groups are listed in the order they are first used, after ungrouped flags.
+func SetGroup(fs *flag.FlagSet, group string, names ...string) {
+	setGroup(fs, group, names)
+}
+
+// SetGroupPflag is SetGroup for a pflag.FlagSet.
+func SetGroupPflag(fs *pflag.FlagSet, group string, names ...string) {
+	setGroup(fs, group, names)
+}
+
+func setGroup(key any, group string, names []string) {
+	updateMeta(key, func(m *setMeta) {
+		if !slices.Contains(m.groupOrder, group) {
+			m.groupOrder = append(m.groupOrder, group)
+		}
+		for _, name := range names {
+			m.groups[name] = group
+		}
+	})
+}
+
+// Hide omits the named flags from generated help. They can still be set.
+// For pflag sets use fs.MarkHidden.
+func Hide(fs *flag.FlagSet, names ...string) {
+	updateMeta(fs, func(m *setMeta) {
+		for _, name := range names {
+			m.hidden[name] = true
+		}
+	})
+}
+
+// Func returns a function suitable for fs.Usage that writes text help to the FlagSet output.
+func (u *Usage) Func() func() {
+	return func() {
+		_ = u.WriteText(u.fs.output())
+	}
+}
+
+// usageEntry is a flag prepared for rendering.
+type usageEntry struct {
+	name     string
+	isBool   bool
+	short    string
+	long     string
+	argName  string
+	usage    string
+	def      string
+	env      string
+	allowed  []string
+	required bool
+	// negatable is set for flags defined with NegatableBool.
+	negatable bool
+}
+
+// usageGroup is a titled list of entries; the first group has no title.
+type usageGroup struct {
+	title   string
+	entries []usageEntry
+}
+
+// collect builds the visible entries grouped for rendering.
+func (u *Usage) collect() []usageGroup {
+	hidden := make(map[string]bool)
+	groups := make(map[string]string)
+	var order []string
+	readMeta(u.fs.key(), func(m *setMeta) {
+		for name := range m.hidden {
+			hidden[name] = true
+		}
+		for name, group := range m.groups {
+			groups[name] = group
+		}
+		order = append(order, m.groupOrder...)
+	})
+	constraints := flagConstraints(u.fs.key())
+
+	byGroup := map[string][]usageEntry{}
+	for _, f := range u.fs.all() {
+		if hidden[f.Name] || f.Hidden || f.Deprecated != "" {
+			continue
+		}
+		e := u.entry(f)
+		e.required = isRequired(constraints, f.Name)
+		byGroup[groups[f.Name]] = append(byGroup[groups[f.Name]], e)
+	}
+
+	result := []usageGroup{{entries: byGroup[""]}}
+	for _, title := range order {
+		if entries := byGroup[title]; len(entries) > 0 {
+			result = append(result, usageGroup{title: title, entries: entries})
+		}
+	}
+	return result
+}
+
+// entry converts a flag to a usageEntry, separating the annotations added
+// by Enum and BindEnv from the usage text.
+func (u *Usage) entry(f *flagInfo) usageEntry {
+	e := usageEntry{name: f.Name, isBool: f.IsBool, argName: f.ArgName, usage: f.PlainUsage}
+	// The flag package accepts one-letter names with a single dash; pflag
+	// needs "--" for any long name.
+	if _, std := u.fs.(stdFlagSet); std && len(f.Name) == 1 && f.Shorthand == "" {
+		e.short = "-" + f.Name
+	} else {
+		e.long = "--" + f.Name
+	}
+	if f.Shorthand != "" {
+		e.short = "-" + f.Shorthand
+	}
+	e.negatable = negationOf(u.fs.key(), f.Name) != ""
+	if e.env = boundEnvKey(u.fs.key(), f.Name); e.env != "" {
+		e.usage = strings.TrimSuffix(e.usage, envUsageSuffix(e.env))
+	}
+	if ev, ok := f.Value.(EnumValuer); ok {
+		e.allowed = ev.AllowedValues()
+		e.usage = strings.TrimSuffix(e.usage, enumUsageSuffix(e.allowed))
+		if e.usage == strings.TrimSpace(enumUsageSuffix(e.allowed)) {
+			e.usage = ""
+		}
+		if e.argName == "value" {
+			e.argName = "string"
+		}
+	}
+	if _, ok := f.Value.(*SecretValue); ok && e.argName == "value" {
+		e.argName = "secret"
+	}
+	if !isZeroDefault(f.DefValue) {
+		e.def = f.DefValue
+		if e.argName == "string" {
+			e.def = strconv.Quote(e.def)
+		}
+	}
+	return e
+}
+
+// isZeroDefault reports whether a default value is not worth showing.
+func isZeroDefault(def string) bool {
+	switch def {
+	case "", "0", "false", "[]", "0s", "map[]", "<nil>", RedactedValue:
+		return true
+	}
+	return false
+}
+
+// spec is the flag column of text output, e.g. "-p, --port int".
+func (e usageEntry) spec(indentLong bool) string {
+	var b strings.Builder
+	switch {
+	case e.short != "" && e.long != "":
+		b.WriteString(e.short + ", " + e.longSpec())
+	case e.short != "":
+		b.WriteString(e.short)
+	default:
+		if indentLong {
+			b.WriteString("    ")
+		}
+		b.WriteString(e.longSpec())
+	}
+	if e.argName != "" {
+		b.WriteString(" " + e.argName)
+	}
+	return b.String()
+}
+
+// glue joins the words of an annotation so wrapText keeps them on one line;
+// callers replace it with a space after wrapping.
+const glue = "\x00"
+
+// annotated is the usage text followed by required, default, env and allowed values.
+func (e usageEntry) annotated() string {
+	parts := []string{}
+	if e.usage != "" {
+		parts = append(parts, e.usage)
+	}
+	if e.required {
+		parts = append(parts, "(required)")
+	}
+	if e.def != "" {
+		parts = append(parts, "(default"+glue+e.def+")")
+	}
+	if e.env != "" {
+		parts = append(parts, "[env:"+glue+e.env+"]")
+	}
+	if len(e.allowed) > 0 {
+		parts = append(parts, "(one"+glue+"of: "+strings.Join(e.allowed, ", ")+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// longSpec is the long flag as shown in help, "--[no-]name" for NegatableBool flags.
+func (e usageEntry) longSpec() string {
+	if e.negatable && e.long != "" {
+		return "--[no-]" + e.name
+	}
+	return e.long
+}
+
+// names is the comma-separated flag names, e.g. "-p, --port".
+func (e usageEntry) names() string {
+	var names []string
+	for _, n := range []string{e.short, e.longSpec()} {
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func (u *Usage) synopsis() string {
+	if u.Synopsis != "" {
+		return u.Synopsis
+	}
+	if u.Args == nil {
+		return u.Name + " [flags]"
+	}
+	parts := []string{u.Name}
+	if args := u.Args.String(); args != "" {
+		parts = append(parts, args)
+	}
+	var entries []usageEntry
+	for _, g := range u.collect() {
+		entries = append(entries, g.entries...)
+	}
+	if len(entries) > maxSynopsisFlags {
+		return strings.Join(append(parts, "[flags]"), " ")
+	}
+	for _, e := range entries {
+		spec := e.longSpec()
+		if spec == "" {
+			spec = e.short
+		}
+		if e.argName != "" {
+			spec += " " + e.argName
+		}
+		if !e.required {
+			spec = "[" + spec + "]"
+		}
+		parts = append(parts, spec)
+	}
+	return strings.Join(parts, " ")
+}
+
+// positionals returns the positionals of Usage.Args that have a description.
+func (u *Usage) positionals() []Positional {
+	if u.Args == nil {
+		return nil
+	}
+	var described []Positional
+	for _, p := range u.Args.positionals {
+		if p.Usage != "" {
+			described = append(described, p)
+		}
+	}
+	return described
+}
+
+// terminalWidth reports the terminal width; tests replace it.
+var terminalWidth = stdoutWidth
+
+// width returns the text wrap width: Width, the terminal on stdout, $COLUMNS,
+// then 80.
+func (u *Usage) width() int {
+	if u.Width > 0 {
+		return u.Width
+	}
+	if cols := terminalWidth(); cols > 0 {
+		return cols
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return defaultUsageWidth
+}
+
+// WriteText writes aligned, wrapped plain-text help to w.
+func (u *Usage) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	width := u.width()
+	fmt.Fprintf(bw, "Usage: %s\n", u.synopsis())
+	if u.Description != "" {
+		bw.WriteString("\n")
+		for _, line := range wrapText(u.Description, width) {
+			fmt.Fprintln(bw, line)
+		}
+	}
+
+	if args := u.positionals(); len(args) > 0 {
+		argCol := 0
+		for _, p := range args {
+			argCol = max(argCol, len(p.placeholder()))
+		}
+		bw.WriteString("\nArguments:\n")
+		for _, p := range args {
+			spec := "  " + p.placeholder()
+			for _, line := range wrapText(p.Usage, max(width-argCol-6, 20)) {
+				fmt.Fprintf(bw, "%-*s%s\n", argCol+6, spec, line)
+				spec = ""
+			}
+		}
+	}
+
+	groups := u.collect()
+	indentLong := false
+	column := 0
+	for _, g := range groups {
+		for _, e := range g.entries {
+			indentLong = indentLong || (e.short != "" && e.long != "")
+		}
+	}
+	for _, g := range groups {
+		for _, e := range g.entries {
+			if n := len(e.spec(indentLong)); n > column && n <= maxUsageFlagColumn {
+				column = n
+			}
+		}
+	}
+	descCol := column + 6
+	descWidth := width - descCol
+	if descWidth < 20 {
+		descWidth = 20
+	}
+
+	for _, g := range groups {
+		if len(g.entries) == 0 {
+			continue
+		}
+		title := g.title
+		if title == "" {
+			title = "Flags"
+		}
+		fmt.Fprintf(bw, "\n%s:\n", title)
+		for _, e := range g.entries {
+			spec := "  " + e.spec(indentLong)
+			lines := wrapText(e.annotated(), descWidth)
+			if len(lines) == 0 {
+				fmt.Fprintln(bw, spec)
+				continue
+			}
+			if len(spec)+2 > descCol {
+				fmt.Fprintln(bw, spec)
+				spec = ""
+			}
+			for i, line := range lines {
+				if i > 0 {
+					spec = ""
+				}
+				fmt.Fprintf(bw, "%-*s%s\n", descCol, spec, strings.ReplaceAll(line, glue, " "))
+			}
+		}
+	}
+	if lines := u.constraintLines(); len(lines) > 0 {
+		bw.WriteString("\nConstraints:\n")
+		for _, line := range lines {
+			for i, l := range wrapText(line, width-4) {
+				indent := "  "
+				if i > 0 {
+					indent = "    "
+				}
+				fmt.Fprintln(bw, indent+l)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// constraintLines describes the group constraints registered on the FlagSet.
+func (u *Usage) constraintLines() []string {
+	var lines []string
+	for _, c := range flagConstraints(u.fs.key()) {
+		if line := c.describe(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// WriteMarkdown writes help as a Markdown section with one table per group.
+func (u *Usage) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "## %s\n\n", u.Name)
+	if u.Description != "" {
+		fmt.Fprintf(bw, "%s\n\n", u.Description)
+	}
+	fmt.Fprintf(bw, "```\n%s\n```\n", u.synopsis())
+
+	if args := u.positionals(); len(args) > 0 {
+		bw.WriteString("\n### Arguments\n\n")
+		bw.WriteString("| Argument | Description |\n")
+		bw.WriteString("|----------|-------------|\n")
+		for _, p := range args {
+			fmt.Fprintf(bw, "| %s | %s |\n", markdownCode(p.placeholder()), markdownCell(p.Usage))
+		}
+	}
+
+	for _, g := range u.collect() {
+		if len(g.entries) == 0 {
+			continue
+		}
+		title := g.title
+		if title == "" {
+			title = "Flags"
+		}
+		fmt.Fprintf(bw, "\n### %s\n\n", title)
+		bw.WriteString("| Flag | Description | Default | Environment |\n")
+		bw.WriteString("|------|-------------|---------|-------------|\n")
+		for _, e := range g.entries {
+			flagCol := "`" + strings.TrimSpace(e.spec(false)) + "`"
+			desc := e.usage
+			if e.required {
+				desc = strings.TrimSpace(desc + " (required)")
+			}
+			if len(e.allowed) > 0 {
+				desc = strings.TrimSpace(desc + " (one of: `" + strings.Join(e.allowed, "`, `") + "`)")
+			}
+			fmt.Fprintf(bw, "| %s | %s | %s | %s |\n",
+				markdownCell(flagCol), markdownCell(desc), markdownCode(e.def), markdownCode(e.env))
+		}
+	}
+	if lines := u.constraintLines(); len(lines) > 0 {
+		bw.WriteString("\n### Constraints\n\n")
+		for _, line := range lines {
+			fmt.Fprintf(bw, "- %s\n", line)
+		}
+	}
+	return bw.Flush()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return markdownCell("`" + s + "`")
+}
+
+// WriteMan writes help as a roff man page (section 1).
+func (u *Usage) WriteMan(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, ".TH \"%s\" \"1\"\n", roffEscape(strings.ToUpper(u.Name)))
+	bw.WriteString(".SH NAME\n")
+	name := roffEscape(u.Name)
+	if summary, _, _ := strings.Cut(u.Description, "\n"); summary != "" {
+		fmt.Fprintf(bw, "%s \\- %s\n", name, roffEscape(summary))
+	} else {
+		fmt.Fprintln(bw, name)
+	}
+	synopsis := roffLine(u.synopsis())
+	if rest, ok := strings.CutPrefix(synopsis, name); ok {
+		synopsis = `\fB` + name + `\fR` + rest
+	}
+	fmt.Fprintf(bw, ".SH SYNOPSIS\n%s\n", synopsis)
+	if u.Description != "" {
+		fmt.Fprintf(bw, ".SH DESCRIPTION\n%s\n", roffLine(u.Description))
+	}
+
+	if args := u.positionals(); len(args) > 0 {
+		bw.WriteString(".SH ARGUMENTS\n")
+		for _, p := range args {
+			fmt.Fprintf(bw, ".TP\n\\fI%s\\fR\n%s\n", roffEscape(p.placeholder()), roffLine(p.Usage))
+		}
+	}
+
+	var envs []usageEntry
+	bw.WriteString(".SH OPTIONS\n")
+	for _, g := range u.collect() {
+		if len(g.entries) == 0 {
+			continue
+		}
+		if g.title != "" {
+			fmt.Fprintf(bw, ".SS \"%s\"\n", roffEscape(g.title))
+		}
+		for _, e := range g.entries {
+			var names []string
+			for _, n := range []string{e.short, e.longSpec()} {
+				if n != "" {
+					names = append(names, `\fB`+roffEscape(n)+`\fR`)
+				}
+			}
+			head := strings.Join(names, ", ")
+			if e.argName != "" {
+				head += ` \fI` + roffEscape(e.argName) + `\fR`
+			}
+			fmt.Fprintf(bw, ".TP\n%s\n%s\n", head, roffLine(strings.ReplaceAll(e.annotated(), glue, " ")))
+			if e.env != "" {
+				envs = append(envs, e)
+			}
+		}
+	}
+	if lines := u.constraintLines(); len(lines) > 0 {
+		bw.WriteString(".SH CONSTRAINTS\n")
+		for _, line := range lines {
+			fmt.Fprintf(bw, ".IP \\(bu 2\n%s\n", roffLine(line))
+		}
+	}
+	if len(envs) > 0 {
+		bw.WriteString(".SH ENVIRONMENT\n")
+		for _, e := range envs {
+			fmt.Fprintf(bw, ".TP\n\\fB%s\\fR\nSets %s.\n", roffEscape(e.env), roffEscape(e.names()))
+		}
+	}
+	return bw.Flush()
+}
+
+// roffEscape escapes backslashes and hyphens for roff.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+// roffLine escapes text and protects lines that roff would treat as requests.
+func roffLine(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapText splits s into lines of at most width runes, breaking at spaces.
+// Existing newlines are kept; words longer than width get a line of their own.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			if para != "" || len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			if len([]rune(line))+1+len([]rune(word)) > width {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,226 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func newUsageTestFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 8080, "Server `port` to listen on for incoming HTTP connections from clients")
+	fs.String("host", "localhost", "Listen host")
+	fs.Bool("v", false, "Verbose output")
+	fs.Duration("timeout", 5*time.Second, "Request timeout")
+	fs.String("internal", "", "Internal knob")
+	Enum(fs, "log-level", "info", []string{"debug", "info"}, false, "Log level")
+	SetGroup(fs, "Network", "port", "host")
+	Hide(fs, "internal")
+	BindEnv(fs, "APP")
+	return fs
+}
+
+func TestUsage_WriteText(t *testing.T) {
+	u := NewUsage(newUsageTestFlagSet())
+	u.Description = "App serves things."
+	u.Width = 70
+
+	var buf bytes.Buffer
+	if err := u.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	want := `Usage: app [flags]
+
+App serves things.
+
+Flags:
+  --log-level string    Log level (default "info")
+                        [env: APP_LOG_LEVEL] (one of: debug, info)
+  --timeout duration    Request timeout (default 5s)
+                        [env: APP_TIMEOUT]
+  -v                    Verbose output [env: APP_V]
+
+Network:
+  --host string         Listen host (default "localhost")
+                        [env: APP_HOST]
+  --port port           Server port to listen on for incoming HTTP
+                        connections from clients (default 8080)
+                        [env: APP_PORT]
+`
+	if buf.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestUsage_WriteMarkdown(t *testing.T) {
+	u := NewUsage(newUsageTestFlagSet())
+	u.Synopsis = "app [flags] <file>"
+
+	var buf bytes.Buffer
+	if err := u.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"## app\n",
+		"```\napp [flags] <file>\n```\n",
+		"### Flags\n",
+		"| `--log-level string` | Log level (one of: `debug`, `info`) | `\"info\"` | `APP_LOG_LEVEL` |\n",
+		"| `-v` | Verbose output |  | `APP_V` |\n",
+		"### Network\n",
+		"| `--port port` | Server port to listen on for incoming HTTP connections from clients | `8080` | `APP_PORT` |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteMarkdown() missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "internal") {
+		t.Error("WriteMarkdown() should omit hidden flags")
+	}
+}
+
+func TestUsage_WriteMan(t *testing.T) {
+	u := NewUsage(newUsageTestFlagSet())
+	u.Description = "Serve things.\n.Dotted line"
+
+	var buf bytes.Buffer
+	if err := u.WriteMan(&buf); err != nil {
+		t.Fatalf("WriteMan() failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		".TH \"APP\" \"1\"\n",
+		".SH NAME\napp \\- Serve things.\n",
+		".SH SYNOPSIS\n\\fBapp\\fR [flags]\n",
+		"\\&.Dotted line\n",
+		".TP\n\\fB\\-\\-log\\-level\\fR \\fIstring\\fR\nLog level (default \"info\") [env: APP_LOG_LEVEL] (one of: debug, info)\n",
+		".SS \"Network\"\n",
+		".SH ENVIRONMENT\n",
+		".TP\n\\fBAPP_PORT\\fR\nSets \\-\\-port.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteMan() missing %q in:\n%s", want, out)
+		}
+	}
+
+	fs := flag.NewFlagSet("bare", flag.ContinueOnError)
+	fs.Bool("x", false, "")
+	buf.Reset()
+	if err := NewUsage(fs).WriteMan(&buf); err != nil {
+		t.Fatalf("WriteMan() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), ".SH NAME\nbare\n") || strings.Contains(buf.String(), "ENVIRONMENT") {
+		t.Errorf("WriteMan() for bare set =\n%s", buf.String())
+	}
+}
+
+func TestUsagePflag(t *testing.T) {
+	fs := pflag.NewFlagSet("tool", pflag.ContinueOnError)
+	fs.IntP("port", "p", 8080, "Server port")
+	fs.Bool("dry-run", false, "Print actions only")
+	fs.String("old", "", "Old option")
+	fs.String("secret", "", "Secret option")
+	fs.StringSlice("tag", nil, "Tags")
+	fs.Bool("v", false, "Verbose output")
+	if err := fs.MarkDeprecated("old", "use --new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.MarkHidden("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	u := NewUsagePflag(fs)
+	u.Width = 80
+	var buf bytes.Buffer
+	if err := u.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	want := `Usage: tool [flags]
+
+Flags:
+      --dry-run        Print actions only
+  -p, --port int       Server port (default 8080)
+      --tag strings    Tags
+      --v              Verbose output
+`
+	if buf.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestUsage_Func(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.String("name", "", "Name to greet")
+	fs.Usage = NewUsage(fs).Func()
+	if err := fs.Parse([]string{"-h"}); err != flag.ErrHelp {
+		t.Fatalf("fs.Parse(-h) = %v, want ErrHelp", err)
+	}
+	if !strings.Contains(buf.String(), "Usage: app [flags]") || !strings.Contains(buf.String(), "--name string") {
+		t.Errorf("usage output = %q", buf.String())
+	}
+}
+
+func TestUsage_Width(t *testing.T) {
+	saved := terminalWidth
+	t.Cleanup(func() { terminalWidth = saved })
+	terminalWidth = func() int { return 0 }
+	t.Setenv("COLUMNS", "120")
+	if got := (&Usage{}).width(); got != 120 {
+		t.Errorf("width() with COLUMNS=120 = %d", got)
+	}
+	t.Setenv("COLUMNS", "bogus")
+	if got := (&Usage{}).width(); got != defaultUsageWidth {
+		t.Errorf("width() with invalid COLUMNS = %d, want %d", got, defaultUsageWidth)
+	}
+	// The terminal size wins over $COLUMNS.
+	terminalWidth = func() int { return 100 }
+	t.Setenv("COLUMNS", "120")
+	if got := (&Usage{}).width(); got != 100 {
+		t.Errorf("width() on a 100-column terminal = %d", got)
+	}
+	if got := (&Usage{Width: 60}).width(); got != 60 {
+		t.Errorf("width() with Width = %d, want 60", got)
+	}
+}
+
+func TestUsage_LongFlagName(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("a-very-long-flag-name-that-exceeds-the-column", "", "Long one")
+	fs.Bool("x", false, "")
+	Enum(fs, "mode", "", []string{"a", "b"}, true, "")
+	u := NewUsage(fs)
+	u.Width = 60
+
+	var buf bytes.Buffer
+	if err := u.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	want := `Usage: app [flags]
+
+Flags:
+  --a-very-long-flag-name-that-exceeds-the-column string
+                   Long one
+  --mode string    (one of: a, b)
+  -x
+`
+	if buf.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("one two three\n\nfour", 8)
+	want := []string{"one two", "three", "", "four"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText() = %q, want %q", got, want)
+	}
+	if got := wrapText("", 10); len(got) != 0 {
+		t.Errorf("wrapText(\"\") = %q, want empty", got)
+	}
+}
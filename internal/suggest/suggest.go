@@ -0,0 +1,100 @@
+// Package suggest finds the values a user most likely meant when they
+// mistype a flag, command or enum value.
+package suggest
+
+import (
+	"slices"
+	"strings"
+)
+
+// Distance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent characters needed to turn a into b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rows are enough: the previous two for transpositions and the current one.
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// maxDistance is the largest edit distance still considered a typo of input.
+func maxDistance(input string) int {
+	switch n := len([]rune(input)); {
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Suggest returns the candidates close to input, case-insensitively: those
+// within a small edit distance and those input is a prefix of (for inputs of
+// two or more characters). Results are ordered by distance, then by name,
+// without duplicates.
+func Suggest(input string, candidates []string) []string {
+	if input == "" {
+		return nil
+	}
+	type match struct {
+		value    string
+		distance int
+	}
+	lower := strings.ToLower(input)
+	limit := maxDistance(input)
+	var matches []match
+	for _, candidate := range candidates {
+		if candidate == input || slices.ContainsFunc(matches, func(m match) bool { return m.value == candidate }) {
+			continue
+		}
+		d := Distance(lower, strings.ToLower(candidate))
+		if d <= limit || (len(lower) >= 2 && strings.HasPrefix(strings.ToLower(candidate), lower)) {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.value, b.value)
+	})
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.value
+	}
+	return result
+}
+
+// Phrase formats suggestions as " (did you mean X?)" or
+// " (did you mean X or Y?)", quoting each with quote. It returns "" when
+// there are no suggestions.
+func Phrase(suggestions []string, quote func(string) string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = quote(s)
+	}
+	return " (did you mean " + strings.Join(quoted, " or ") + "?)"
+}
@@ -0,0 +1,60 @@
+package suggest
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"port", "port", 0},
+		{"prot", "port", 1},
+		{"por", "port", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"port", "host", "verbose", "version", "log-level", "p", "port"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"prot", []string{"port"}},
+		{"PORT", []string{"port"}},
+		{"ver", []string{"verbose", "version"}},
+		{"verison", []string{"version"}},
+		{"hots", []string{"host"}},
+		{"loglevel", []string{"log-level"}},
+		{"x", []string{"p"}},
+		{"database", nil},
+		{"", nil},
+		{"port", nil},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.input, candidates); !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPhrase(t *testing.T) {
+	if got := Phrase(nil, strconv.Quote); got != "" {
+		t.Errorf("Phrase(nil) = %q, want empty", got)
+	}
+	if got := Phrase([]string{"a", "b"}, strconv.Quote); got != ` (did you mean "a" or "b"?)` {
+		t.Errorf("Phrase() = %q", got)
+	}
+}
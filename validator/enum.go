@@ -2,7 +2,10 @@ package validator
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/soulteary/cli-kit/internal/suggest"
 )
 
 // ErrInvalidEnumValue is returned when a value is not in the allowed enum list
@@ -16,7 +19,8 @@ var ErrInvalidEnumValue = fmt.Errorf("value is not in allowed enum values")
 //   - caseSensitive: Whether comparison should be case-sensitive
 //
 // Returns:
-//   - error: Returns ErrInvalidEnumValue if value is not in allowed list, nil otherwise
+//   - error: Returns ErrInvalidEnumValue if value is not in allowed list, nil otherwise.
+//     The message suggests close allowed values, e.g. (did you mean "info"?)
 func ValidateEnum(value string, allowedValues []string, caseSensitive bool) error {
 	if len(allowedValues) == 0 {
 		return fmt.Errorf("allowed values list cannot be empty")
@@ -38,7 +42,8 @@ func ValidateEnum(value string, allowedValues []string, caseSensitive bool) erro
 		}
 	}
 
-	return fmt.Errorf("%w: %q, allowed values: %v", ErrInvalidEnumValue, value, allowedValues)
+	hint := suggest.Phrase(suggest.Suggest(value, allowedValues), strconv.Quote)
+	return fmt.Errorf("%w: %q, allowed values: %v%s", ErrInvalidEnumValue, value, allowedValues, hint)
 }
 
 // ValidateEnumCaseInsensitive is a convenience function for case-insensitive enum validation
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +77,25 @@ func TestValidateEnumCaseSensitive(t *testing.T) {
 		t.Error("ValidateEnumCaseSensitive() error = nil, want error")
 	}
 }
+
+func TestValidateEnum_Suggestion(t *testing.T) {
+	allowedValues := []string{"debug", "info", "warn", "error"}
+
+	err := ValidateEnum("inof", allowedValues, true)
+	if err == nil || !strings.HasSuffix(err.Error(), `(did you mean "info"?)`) {
+		t.Errorf("ValidateEnum(inof) error = %v, want a suggestion for info", err)
+	}
+	if !errors.Is(err, ErrInvalidEnumValue) {
+		t.Error("ValidateEnum() error should wrap ErrInvalidEnumValue")
+	}
+
+	err = ValidateEnum("DEBUG", allowedValues, true)
+	if err == nil || !strings.Contains(err.Error(), `did you mean "debug"`) {
+		t.Errorf("ValidateEnum(DEBUG) case-sensitive error = %v, want a suggestion for debug", err)
+	}
+
+	err = ValidateEnum("trace", allowedValues, true)
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("ValidateEnum(trace) error = %v, want no suggestion", err)
+	}
+}